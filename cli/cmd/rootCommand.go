@@ -1,11 +1,18 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"os"
 
+	config_cmd "github.com/leodahal4/dev-kit/cli/config-cmd"
+	"github.com/leodahal4/dev-kit/cli/env"
 	init_cmd "github.com/leodahal4/dev-kit/cli/init-cmd"
+	"github.com/leodahal4/dev-kit/cli/project"
 	"github.com/leodahal4/dev-kit/cli/run"
+	"github.com/leodahal4/dev-kit/cli/secret"
 	"github.com/leodahal4/dev-kit/cli/utils"
+	"github.com/leodahal4/dev-kit/cli/workspace"
 	"github.com/leodahal4/dev-kit/config"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -47,9 +54,21 @@ var Cmd = &cobra.Command{
 
 func Execute() {
 	Cmd.Flags().BoolP("version", "v", false, "print DevKit version")
-	Cmd.PersistentFlags().StringVarP(&cfgPath, "config", "c", "", "base project directory eg. github.com/spf13/")
+	Cmd.PersistentFlags().StringVarP(&cfgPath, "config", "c", "", "configuration file whose settings take precedence over every other layer")
 	Cmd.AddCommand(init_cmd.NewInitCommand())
 	Cmd.AddCommand(run.NewRun())
+	Cmd.AddCommand(run.NewUpCommand())
+	Cmd.AddCommand(run.NewDownCommand())
+	Cmd.AddCommand(run.NewPsCommand())
+	Cmd.AddCommand(run.NewLogsCommand())
+	Cmd.AddCommand(run.NewExecCommand())
+	Cmd.AddCommand(run.NewTaskCommand())
+	Cmd.AddCommand(project.NewProjectCommand())
+	Cmd.AddCommand(env.NewEnvCommand())
+	Cmd.AddCommand(config_cmd.NewConfigCommand())
+	Cmd.AddCommand(secret.NewSecretCommand())
+	Cmd.AddCommand(workspace.NewSyncCommand())
+	Cmd.AddCommand(workspace.NewStatusCommand())
 	Cmd.AddGroup(&cobra.Group{
 		ID:    "init",
 		Title: "Init Commands",
@@ -58,9 +77,20 @@ func Execute() {
 	err := Cmd.Execute()
 	if err != nil {
 		logrus.Errorf("Err: %s", err.Error())
+		os.Exit(exitCode(err))
 	}
 }
 
+// exitCode returns the exit status carried by err, such as the aggregated
+// status of "devkit run", defaulting to 1
+func exitCode(err error) int {
+	var coder interface{ ExitCode() int }
+	if errors.As(err, &coder) && coder.ExitCode() > 0 {
+		return coder.ExitCode()
+	}
+	return 1
+}
+
 func init() {
 	cobra.OnInitialize(initConfig)
 }
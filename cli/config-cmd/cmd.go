@@ -0,0 +1,23 @@
+package config_cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewConfigCommand() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the devkit configuration",
+		Long:  "Read and change the settings of devkit, edit its configuration file and upgrade it",
+	}
+
+	configCmd.AddCommand(NewGetCommand())
+	configCmd.AddCommand(NewSetCommand())
+	configCmd.AddCommand(NewUnsetCommand())
+	configCmd.AddCommand(NewListCommand())
+	configCmd.AddCommand(NewEditCommand())
+	configCmd.AddCommand(NewPathCommand())
+	configCmd.AddCommand(NewMigrateCommand())
+
+	return configCmd
+}
@@ -0,0 +1,138 @@
+package config_cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	removedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	addedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	hunkStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+)
+
+// diffContext is the number of unchanged lines shown around the changes
+const diffContext = 3
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// printDiff prints the changes from before to after as a unified diff
+func printDiff(w io.Writer, beforeName, before, afterName, after string) {
+	ops := diffLines(splitLines(before), splitLines(after))
+
+	fmt.Fprintln(w, removedStyle.Render("--- "+beforeName))
+	fmt.Fprintln(w, addedStyle.Render("+++ "+afterName))
+	for start := 0; start < len(ops); {
+		// find the next change and the end of its hunk, which extends while
+		// changes are closer than twice the context
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			return
+		}
+		end, unchanged := first, 0
+		for i := first; i < len(ops) && unchanged <= 2*diffContext; i++ {
+			if ops[i].kind == ' ' {
+				unchanged++
+				continue
+			}
+			unchanged, end = 0, i+1
+		}
+
+		from, to := max(first-diffContext, start), min(end+diffContext, len(ops))
+		printHunk(w, ops, from, to)
+		start = to
+	}
+}
+
+func printHunk(w io.Writer, ops []diffOp, from, to int) {
+	// line numbers of the hunk in both texts, 1-based
+	beforeLine, afterLine := 1, 1
+	for _, op := range ops[:from] {
+		if op.kind != '+' {
+			beforeLine++
+		}
+		if op.kind != '-' {
+			afterLine++
+		}
+	}
+	beforeCount, afterCount := 0, 0
+	for _, op := range ops[from:to] {
+		if op.kind != '+' {
+			beforeCount++
+		}
+		if op.kind != '-' {
+			afterCount++
+		}
+	}
+
+	fmt.Fprintln(w, hunkStyle.Render(fmt.Sprintf("@@ -%d,%d +%d,%d @@", beforeLine, beforeCount, afterLine, afterCount)))
+	for _, op := range ops[from:to] {
+		line := string(op.kind) + op.line
+		switch op.kind {
+		case '-':
+			line = removedStyle.Render(line)
+		case '+':
+			line = addedStyle.Render(line)
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+func splitLines(text string) []string {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// diffLines returns the edit script turning a into b, based on their longest
+// common subsequence of lines
+func diffLines(a, b []string) []diffOp {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i, j = i+1, j+1
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
@@ -0,0 +1,105 @@
+package config_cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/leodahal4/dev-kit/cli/utils"
+	"github.com/leodahal4/dev-kit/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func NewEditCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "edit",
+		Short: "Edit the configuration file in your editor",
+		Long: `Open a copy of the configuration file in $VISUAL or $EDITOR, and save it once
+the editor exits if it is valid. An invalid file can be edited again or
+discarded.`,
+		Args: cobra.NoArgs,
+		RunE: Edit,
+	}
+}
+
+// Edit lets the user edit the configuration file, and saves it once validated
+func Edit(cmd *cobra.Command, args []string) error {
+	path := config.DefaultConfigPath()
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading config file: %v", err)
+	}
+
+	// the copy keeps the extension, so that editors highlight it as YAML
+	tmp, err := os.CreateTemp("", "devkit-config-*.yaml")
+	if err != nil {
+		return fmt.Errorf("error creating temporary file: %v", err)
+	}
+	_, err = tmp.Write(original)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("error writing %s: %v", tmp.Name(), err)
+	}
+
+	for {
+		if err := runEditor(tmp.Name()); err != nil {
+			_ = os.Remove(tmp.Name())
+			return err
+		}
+		edited, err := os.ReadFile(tmp.Name())
+		if err != nil {
+			return fmt.Errorf("error reading %s: %v", tmp.Name(), err)
+		}
+		if bytes.Equal(edited, original) {
+			_ = os.Remove(tmp.Name())
+			logrus.Info("No changes")
+			return nil
+		}
+
+		if _, err := config.ParseConfig(edited); err != nil {
+			logrus.Errorf("The configuration is invalid: %v", err)
+			answer := strings.ToLower(utils.AskInput("Edit it again? Your changes are discarded otherwise (yes/no): ", ""))
+			if answer == "yes" || answer == "y" {
+				continue
+			}
+			_ = os.Remove(tmp.Name())
+			return fmt.Errorf("discarded the invalid configuration")
+		}
+
+		if err := config.ReplaceConfig(original, edited); err != nil {
+			return fmt.Errorf("%v, your changes are kept in %s", err, tmp.Name())
+		}
+		_ = os.Remove(tmp.Name())
+		logrus.Infof("Saved %s", path)
+		return nil
+	}
+}
+
+// runEditor opens path in the editor of the user and waits for it to exit
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	// the editor may come with arguments, such as "code --wait"
+	argv := append(utils.SplitCommandLine(editor), path)
+	editorCmd := exec.Command(argv[0], argv[1:]...)
+	editorCmd.Stdin, editorCmd.Stdout, editorCmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := editorCmd.Run(); err != nil {
+		return fmt.Errorf("error running the editor %s: %v", editor, err)
+	}
+	return nil
+}
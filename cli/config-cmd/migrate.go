@@ -0,0 +1,66 @@
+package config_cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/leodahal4/dev-kit/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func NewMigrateCommand() *cobra.Command {
+	migrateCmd := &cobra.Command{
+		Use:   "migrate [file]",
+		Short: "Upgrade a configuration file to the current schema",
+		Long: `Upgrade a configuration file to the current schema version, showing the
+changes made. The devkit configuration is migrated by default, a JSON file such
+as the config.json of the server can be given instead. The previous content is
+kept as <file>.bak.
+
+Older configurations are upgraded in memory when they are loaded, and saved at
+the current version by the next command changing them.`,
+		Example: "  devkit config migrate --dry-run\n  devkit config migrate\n  devkit config migrate ./config.json",
+		Args:    cobra.MaximumNArgs(1),
+		RunE:    Migrate,
+	}
+
+	migrateCmd.Flags().Bool("dry-run", false, "only show the changes, without writing the file")
+
+	return migrateCmd
+}
+
+// Migrate upgrades the configuration file given as argument, or the devkit
+// one, to the current schema version
+func Migrate(cmd *cobra.Command, args []string) error {
+	path := config.DefaultConfigPath()
+	if len(args) > 0 {
+		path = args[0]
+	}
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	migration, err := config.MigrateFile(path, dryRun)
+	if err != nil {
+		return err
+	}
+	if migration.From == migration.To {
+		logrus.Infof("%s is already at version %d", path, migration.To)
+		return nil
+	}
+
+	for _, step := range migration.Steps {
+		logrus.Infof("Version %s", step)
+	}
+	fmt.Fprintln(os.Stdout)
+	printDiff(os.Stdout,
+		fmt.Sprintf("%s (version %d)", path, migration.From), string(migration.Before),
+		fmt.Sprintf("%s (version %d)", path, migration.To), string(migration.After))
+	fmt.Fprintln(os.Stdout)
+
+	if dryRun {
+		logrus.Infof("Dry run, %s was not changed", path)
+		return nil
+	}
+	logrus.Infof("Migrated %s from version %d to %d, the previous content is kept in %s.bak", path, migration.From, migration.To, path)
+	return nil
+}
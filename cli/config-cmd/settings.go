@@ -0,0 +1,196 @@
+package config_cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/leodahal4/dev-kit/cli/utils"
+	"github.com/leodahal4/dev-kit/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func NewGetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:               "get <key>",
+		Short:             "Print the value of a setting",
+		Example:           "  devkit config get log_format",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeKeys,
+		RunE:              Get,
+	}
+}
+
+func NewSetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:               "set <key> <value>",
+		Short:             "Change the value of a setting",
+		Long:              "Change the value of a setting, parsed according to its type: true or false for booleans, a number or a text",
+		Example:           "  devkit config set debug true\n  devkit config set pprof_address localhost:7070",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeKeys,
+		RunE:              Set,
+	}
+}
+
+func NewUnsetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:               "unset <key>",
+		Short:             "Set a setting back to its default value",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeKeys,
+		RunE:              Unset,
+	}
+}
+
+func NewListCommand() *cobra.Command {
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the settings with their values",
+		Long: `List the settings with the values in effect. They are layered, from the lowest
+precedence to the highest: the built-in defaults, the system configuration, the
+user configuration, the .devkit.yaml found from the current directory up, the
+DEVKIT_* environment variables such as DEVKIT_LOG_FORMAT, and the file given
+with --config.`,
+		Example: "  devkit config list --show-origin",
+		Args:    cobra.NoArgs,
+		RunE:    List,
+	}
+
+	utils.AddOutputFlag(listCmd)
+	listCmd.Flags().Bool("show-origin", false, "show where each value comes from")
+
+	return listCmd
+}
+
+func NewPathCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "path",
+		Short: "Print the path of the configuration file",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fmt.Fprintln(cmd.OutOrStdout(), config.DefaultConfigPath())
+			return nil
+		},
+	}
+}
+
+func completeKeys(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var keys []string
+	for _, setting := range config.Settings() {
+		if strings.HasPrefix(setting.Key, toComplete) {
+			keys = append(keys, setting.Key)
+		}
+	}
+	return keys, cobra.ShellCompDirectiveNoFileComp
+}
+
+// Get prints the value of the setting given as argument
+func Get(cmd *cobra.Command, args []string) error {
+	value, err := config.GetConfig().Get(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), value)
+	return nil
+}
+
+// Set sets the setting given as first argument to the second one
+func Set(cmd *cobra.Command, args []string) error {
+	setting, err := config.FindSetting(args[0])
+	if err != nil {
+		return err
+	}
+	err = config.Update(func(cfg *config.GlobalConfig) error {
+		return cfg.Set(setting.Key, args[1])
+	})
+	if err != nil {
+		return err
+	}
+	logrus.Infof("Set %s to %s in %s", setting.Key, utils.Cell(args[1]), config.DefaultConfigPath())
+	warnOverridden(setting.Key)
+	return nil
+}
+
+// warnOverridden warns when the value of the user configuration is not the one
+// in effect, as a layer with a higher precedence sets the setting
+func warnOverridden(key string) {
+	cfg := config.GetConfig()
+	origin := cfg.Origin(key)
+	switch origin.Layer {
+	case config.LayerLocal, config.LayerEnv, config.LayerFlag:
+		value, _ := cfg.Get(key)
+		logrus.Warnf("%s is overridden by %s, the value in effect is %s", key, origin, utils.Cell(value))
+	}
+}
+
+// Unset sets the setting given as argument back to its default
+func Unset(cmd *cobra.Command, args []string) error {
+	setting, err := config.FindSetting(args[0])
+	if err != nil {
+		return err
+	}
+	err = config.Update(func(cfg *config.GlobalConfig) error {
+		return cfg.Unset(setting.Key)
+	})
+	if err != nil {
+		return err
+	}
+	cfg := config.GetConfig()
+	value, _ := cfg.Get(setting.Key)
+	logrus.Infof("Removed %s from %s, its value is now %s from %s", setting.Key, config.DefaultConfigPath(), utils.Cell(value), cfg.Origin(setting.Key))
+	return nil
+}
+
+// settingEntry is a setting along with its value, as listed by "config list"
+type settingEntry struct {
+	config.Setting
+	Value  string         `json:"value"`
+	Origin *config.Origin `json:"origin,omitempty"`
+}
+
+// List prints every setting
+func List(cmd *cobra.Command, args []string) error {
+	format, err := utils.OutputFormat(cmd)
+	if err != nil {
+		return err
+	}
+
+	showOrigin, _ := cmd.Flags().GetBool("show-origin")
+
+	cfg := config.GetConfig()
+	var entries []settingEntry
+	for _, setting := range config.Settings() {
+		value, err := cfg.Get(setting.Key)
+		if err != nil {
+			return err
+		}
+		entry := settingEntry{Setting: setting, Value: value}
+		if showOrigin {
+			origin := cfg.Origin(setting.Key)
+			entry.Origin = &origin
+		}
+		entries = append(entries, entry)
+	}
+	if format == utils.OutputJSON {
+		return utils.PrintJSON(cmd.OutOrStdout(), entries)
+	}
+
+	w := utils.NewTable(cmd.OutOrStdout())
+	if showOrigin {
+		fmt.Fprintln(w, "KEY\tVALUE\tORIGIN")
+		for _, entry := range entries {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", entry.Key, utils.Cell(entry.Value), entry.Origin)
+		}
+		return w.Flush()
+	}
+	fmt.Fprintln(w, "KEY\tVALUE\tTYPE\tDEFAULT")
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", entry.Key, utils.Cell(entry.Value), entry.Type, utils.Cell(entry.Default))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,293 @@
+package detect
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Detection describes what was recognised in the directory of an environment
+type Detection struct {
+	Language  string
+	Framework string
+	// Tool is the package manager or build tool in use, such as yarn or gradle
+	Tool string
+
+	// Run, Build and Test are suggested command lines, empty when nothing
+	// sensible could be suggested
+	Run   string
+	Build string
+	Test  string
+}
+
+// detectors are tried in order, the first one recognising the directory wins.
+// Makefile and Dockerfile come last as most projects ship one next to their
+// language manifest.
+var detectors = []func(dir string) (Detection, bool){
+	detectGo,
+	detectNode,
+	detectPython,
+	detectMaven,
+	detectGradle,
+	detectRust,
+	detectMake,
+	detectDocker,
+}
+
+// Detect inspects dir for manifest files and conventions, and reports the
+// language, framework and commands of the project found there
+func Detect(dir string) (Detection, bool) {
+	for _, detector := range detectors {
+		if d, ok := detector(dir); ok {
+			if d.Build == "" || d.Test == "" {
+				fillFromMakefile(dir, &d)
+			}
+			return d, true
+		}
+	}
+	return Detection{}, false
+}
+
+func exists(dir string, names ...string) bool {
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func readFile(dir, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// firstMatch returns the framework of the first marker found in text
+func firstMatch(text string, markers [][2]string) string {
+	for _, m := range markers {
+		if strings.Contains(text, m[0]) {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+func detectGo(dir string) (Detection, bool) {
+	if !exists(dir, "go.mod") {
+		return Detection{}, false
+	}
+	d := Detection{Language: "go", Tool: "go", Run: "go run .", Build: "go build ./...", Test: "go test ./..."}
+	d.Framework = firstMatch(readFile(dir, "go.mod"), [][2]string{
+		{"github.com/gin-gonic/gin", "gin"},
+		{"github.com/labstack/echo", "echo"},
+		{"github.com/gofiber/fiber", "fiber"},
+		{"github.com/go-chi/chi", "chi"},
+		{"github.com/gorilla/mux", "gorilla"},
+	})
+	if !exists(dir, "main.go") && exists(dir, "cmd") {
+		// the main packages live below cmd/, the user has to pick one
+		d.Run = ""
+	}
+	return d, true
+}
+
+type packageJSON struct {
+	Scripts         map[string]string `json:"scripts"`
+	Dependencies    map[string]string `json:"dependencies"`
+	DevDependencies map[string]string `json:"devDependencies"`
+}
+
+func detectNode(dir string) (Detection, bool) {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return Detection{}, false
+	}
+	var pkg packageJSON
+	_ = json.Unmarshal(data, &pkg)
+
+	d := Detection{Language: "node", Tool: "npm"}
+	if exists(dir, "tsconfig.json") {
+		d.Language = "typescript"
+	}
+	switch {
+	case exists(dir, "pnpm-lock.yaml"):
+		d.Tool = "pnpm"
+	case exists(dir, "yarn.lock"):
+		d.Tool = "yarn"
+	}
+
+	dependency := func(name string) bool {
+		_, ok := pkg.Dependencies[name]
+		_, dev := pkg.DevDependencies[name]
+		return ok || dev
+	}
+	for _, f := range [][2]string{
+		{"next", "next"}, {"@nestjs/core", "nest"}, {"nuxt", "nuxt"}, {"@angular/core", "angular"},
+		{"vue", "vue"}, {"react", "react"}, {"svelte", "svelte"}, {"express", "express"}, {"fastify", "fastify"},
+	} {
+		if dependency(f[0]) {
+			d.Framework = f[1]
+			break
+		}
+	}
+
+	script := func(name string) string {
+		if _, ok := pkg.Scripts[name]; !ok {
+			return ""
+		}
+		if name == "start" || name == "test" {
+			return d.Tool + " " + name
+		}
+		return d.Tool + " run " + name
+	}
+	d.Run = script("dev")
+	if d.Run == "" {
+		d.Run = script("start")
+	}
+	d.Build = script("build")
+	d.Test = script("test")
+	return d, true
+}
+
+func detectPython(dir string) (Detection, bool) {
+	pyproject := readFile(dir, "pyproject.toml")
+	requirements := readFile(dir, "requirements.txt")
+	if !exists(dir, "pyproject.toml", "requirements.txt", "setup.py", "manage.py", "Pipfile") {
+		return Detection{}, false
+	}
+
+	d := Detection{Language: "python", Tool: "pip"}
+	// runner prefixes the commands run inside the virtual environment
+	runner, python := "", "python3"
+	switch {
+	case strings.Contains(pyproject, "[tool.poetry]") || exists(dir, "poetry.lock"):
+		d.Tool, runner, python = "poetry", "poetry run ", "python"
+		d.Build = "poetry install"
+	case exists(dir, "Pipfile"):
+		d.Tool, runner, python = "pipenv", "pipenv run ", "python"
+		d.Build = "pipenv install"
+	case requirements != "":
+		d.Build = "pip install -r requirements.txt"
+	default:
+		d.Build = "pip install -e ."
+	}
+	d.Test = runner + python + " -m pytest"
+
+	deps := strings.ToLower(pyproject + requirements)
+	switch {
+	case exists(dir, "manage.py"):
+		d.Framework = "django"
+		d.Run = runner + python + " manage.py runserver"
+		d.Test = runner + python + " manage.py test"
+	case strings.Contains(deps, "fastapi"):
+		d.Framework, d.Run = "fastapi", runner+"uvicorn main:app --reload"
+	case strings.Contains(deps, "flask"):
+		d.Framework, d.Run = "flask", runner+"flask run"
+	case exists(dir, "main.py"):
+		d.Run = runner + python + " main.py"
+	case exists(dir, "app.py"):
+		d.Run = runner + python + " app.py"
+	}
+	return d, true
+}
+
+func detectMaven(dir string) (Detection, bool) {
+	if !exists(dir, "pom.xml") {
+		return Detection{}, false
+	}
+	pom := readFile(dir, "pom.xml")
+	mvn := "mvn"
+	if exists(dir, "mvnw") {
+		mvn = "./mvnw"
+	}
+	d := Detection{Language: "java", Tool: "maven", Run: mvn + " exec:java", Build: mvn + " package", Test: mvn + " test"}
+	if strings.Contains(pom, "spring-boot") {
+		d.Framework, d.Run = "spring-boot", mvn+" spring-boot:run"
+	} else if strings.Contains(pom, "quarkus") {
+		d.Framework, d.Run = "quarkus", mvn+" quarkus:dev"
+	}
+	return d, true
+}
+
+func detectGradle(dir string) (Detection, bool) {
+	if !exists(dir, "build.gradle", "build.gradle.kts") {
+		return Detection{}, false
+	}
+	build := readFile(dir, "build.gradle") + readFile(dir, "build.gradle.kts")
+	gradle := "gradle"
+	if exists(dir, "gradlew") {
+		gradle = "./gradlew"
+	}
+	d := Detection{Language: "java", Tool: "gradle", Run: gradle + " run", Build: gradle + " build", Test: gradle + " test"}
+	if exists(dir, "build.gradle.kts") && strings.Contains(build, "kotlin") {
+		d.Language = "kotlin"
+	}
+	if strings.Contains(build, "org.springframework.boot") {
+		d.Framework, d.Run = "spring-boot", gradle+" bootRun"
+	}
+	return d, true
+}
+
+func detectRust(dir string) (Detection, bool) {
+	if !exists(dir, "Cargo.toml") {
+		return Detection{}, false
+	}
+	cargo := readFile(dir, "Cargo.toml")
+	d := Detection{Language: "rust", Tool: "cargo", Run: "cargo run", Build: "cargo build", Test: "cargo test"}
+	d.Framework = firstMatch(cargo, [][2]string{{"actix-web", "actix"}, {"axum", "axum"}, {"rocket", "rocket"}})
+	return d, true
+}
+
+var makeTarget = regexp.MustCompile(`(?m)^([A-Za-z0-9_.-]+)\s*:([^=]|$)`)
+
+// makeTargets returns the targets declared by the Makefile of dir
+func makeTargets(dir string) []string {
+	var targets []string
+	for _, m := range makeTarget.FindAllStringSubmatch(readFile(dir, "Makefile"), -1) {
+		targets = append(targets, m[1])
+	}
+	return targets
+}
+
+func detectMake(dir string) (Detection, bool) {
+	if !exists(dir, "Makefile") {
+		return Detection{}, false
+	}
+	d := Detection{Language: "make", Tool: "make", Run: "make"}
+	if slices.Contains(makeTargets(dir), "run") {
+		d.Run = "make run"
+	}
+	fillFromMakefile(dir, &d)
+	return d, true
+}
+
+// fillFromMakefile suggests the build and test targets of a Makefile when the
+// language did not provide them
+func fillFromMakefile(dir string, d *Detection) {
+	targets := makeTargets(dir)
+	if d.Build == "" && slices.Contains(targets, "build") {
+		d.Build = "make build"
+	}
+	if d.Test == "" && slices.Contains(targets, "test") {
+		d.Test = "make test"
+	}
+}
+
+func detectDocker(dir string) (Detection, bool) {
+	if !exists(dir, "Dockerfile") {
+		return Detection{}, false
+	}
+	image := strings.ToLower(filepath.Base(dir))
+	return Detection{
+		Language: "docker",
+		Tool:     "docker",
+		Run:      "docker run --rm " + image,
+		Build:    "docker build -t " + image + " .",
+	}, true
+}
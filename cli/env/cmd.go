@@ -0,0 +1,26 @@
+package env
+
+import (
+	"github.com/leodahal4/dev-kit/cli/utils"
+	"github.com/spf13/cobra"
+)
+
+func NewEnvCommand() *cobra.Command {
+	envCmd := &cobra.Command{
+		Use:   "env",
+		Short: "Manage environments",
+		Long:  "List, inspect, edit, move and remove the environments of your projects",
+		PreRun: func(cmd *cobra.Command, args []string) {
+			utils.ParseAndSaveCommand(cmd, args)
+		},
+	}
+
+	envCmd.AddCommand(NewListCommand())
+	envCmd.AddCommand(NewShowCommand())
+	envCmd.AddCommand(NewEditCommand())
+	envCmd.AddCommand(NewMoveCommand())
+	envCmd.AddCommand(NewRemoveCommand())
+	envCmd.AddCommand(NewVarsCommand())
+
+	return envCmd
+}
@@ -0,0 +1,180 @@
+package env
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/leodahal4/dev-kit/cli/form"
+	"github.com/leodahal4/dev-kit/cli/secrets"
+	"github.com/leodahal4/dev-kit/cli/utils"
+	"github.com/leodahal4/dev-kit/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func NewEditCommand() *cobra.Command {
+	editCmd := &cobra.Command{
+		Use:   "edit <project>/<env>",
+		Short: "Edit an environment",
+		Long: `Edit an environment. Values which are not given as flags are asked for in a
+form pre-filled with the current ones. Renaming an environment updates the
+environments depending on it.`,
+		Example:           "  devkit env edit shop/api\n  devkit env edit shop/api --command \"go run ./cmd/api\" --yes",
+		Args:              cobra.ExactArgs(1),
+		Annotations:       utils.ConfigOnly(),
+		ValidArgsFunction: utils.CompleteEnvironments,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			utils.ParseAndSaveCommand(cmd, args)
+		},
+		RunE: Edit,
+	}
+
+	editCmd.Flags().String("name", "", "name of the environment")
+	editCmd.Flags().String("description", "", "description of the environment")
+	editCmd.Flags().String("path", "", "directory of the environment")
+	editCmd.Flags().String("language", "", "language of the environment")
+	editCmd.Flags().String("command", "", "command line running the environment, empty for the language default")
+	editCmd.Flags().Bool("shell", false, "run the command through the system shell")
+	editCmd.Flags().String("build", "", "command line building the environment")
+	editCmd.Flags().String("test", "", "command line testing the environment")
+	utils.AddNonInteractiveFlags(editCmd)
+	_ = editCmd.MarkFlagDirname("path")
+
+	return editCmd
+}
+
+func NewMoveCommand() *cobra.Command {
+	moveCmd := &cobra.Command{
+		Use:               "move <project>/<env> <project>",
+		Aliases:           []string{"mv"},
+		Short:             "Move an environment to another project",
+		Long:              "Move an environment to another project. Environments with dependencies, or which others depend on, cannot be moved.",
+		Example:           "  devkit env move shop/api payments\n  devkit env move shop/api payments --name gateway",
+		Args:              cobra.ExactArgs(2),
+		Annotations:       utils.ConfigOnly(),
+		ValidArgsFunction: completeMove,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			utils.ParseAndSaveCommand(cmd, args)
+		},
+		RunE: Move,
+	}
+
+	moveCmd.Flags().String("name", "", "name of the environment in the other project, its current one by default")
+
+	return moveCmd
+}
+
+func completeMove(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 1 {
+		return utils.CompleteProjects(cmd, nil, toComplete)
+	}
+	return utils.CompleteEnvironments(cmd, args, toComplete)
+}
+
+// Edit updates the environment referenced by the first argument
+func Edit(cmd *cobra.Command, args []string) error {
+	cfg := config.GetConfig()
+	project, env, err := cfg.FindEnvironment(args[0])
+	if err != nil {
+		return err
+	}
+
+	command := env.Command
+	if command != "" && !env.Shell {
+		command = utils.JoinCommandLine(env.Command, env.Args)
+	}
+
+	prompt := utils.NewPrompter(cmd)
+	name := prompt.Text("name", "Name", env.Name, form.Required(func(name string) error {
+		if name == env.Name {
+			return nil
+		}
+		return cfg.ValidateEnvName(project.ID, name)
+	}))
+	description := prompt.Text("description", "Description", env.Description, nil)
+	path := prompt.Dir("path", "Path", env.Path, form.Required(func(path string) error {
+		if path == env.Path {
+			return nil
+		}
+		return form.ExistingDir(path)
+	}))
+	language := prompt.Text("language", "Language", env.Language, nil)
+	commandLine := prompt.Text("command", "Command (empty for language default)", command, nil)
+	shell := prompt.Bool("shell", "Run the command through a shell", env.Shell)
+	build := prompt.Text("build", "Build command", env.Build, nil)
+	test := prompt.Text("test", "Test command", env.Test, nil)
+	if err := prompt.Run(fmt.Sprintf("Edit environment %s/%s", project.Name, env.Name)); err != nil {
+		return err
+	}
+
+	absPath, err := filepath.Abs(path())
+	if err != nil {
+		return fmt.Errorf("error resolving path: %v", err)
+	}
+	projectID, oldName := project.ID, env.Name
+	err = secrets.MoveEnvironment(projectID, oldName, projectID, name(), func() error {
+		return config.Update(func(cfg *config.GlobalConfig) error {
+			project, err := cfg.FindProject(projectID)
+			if err != nil {
+				return err
+			}
+			env, err := project.FindEnvironment(oldName)
+			if err != nil {
+				return err
+			}
+			env.Description, env.Language, env.Build, env.Test = description(), language(), build(), test()
+			env.Path = absPath
+			env.Command, env.Args, env.Shell = utils.ParseCommandLine(commandLine(), shell())
+
+			// renaming updates the dependents, env cannot be used afterwards
+			if err := project.RenameEnvironment(oldName, name()); err != nil {
+				return err
+			}
+			project.RefreshTools()
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+	logrus.Infof("Updated environment '%s/%s'", project.Name, name())
+	return nil
+}
+
+// Move moves the environment referenced by the first argument to the project
+// given as second argument
+func Move(cmd *cobra.Command, args []string) error {
+	cfg := config.GetConfig()
+	from, env, err := cfg.FindEnvironment(args[0])
+	if err != nil {
+		return err
+	}
+	to, err := cfg.FindProject(args[1])
+	if err != nil {
+		return err
+	}
+
+	name, _ := cmd.Flags().GetString("name")
+	if name == "" {
+		name = env.Name
+	}
+	oldName, fromID, toID := env.Name, from.ID, to.ID
+	err = secrets.MoveEnvironment(fromID, oldName, toID, name, func() error {
+		return config.Update(func(cfg *config.GlobalConfig) error {
+			from, err := cfg.FindProject(fromID)
+			if err != nil {
+				return err
+			}
+			to, err := cfg.FindProject(toID)
+			if err != nil {
+				return err
+			}
+			return from.MoveEnvironment(oldName, to, name)
+		})
+	})
+	if err != nil {
+		return err
+	}
+	logrus.Infof("Moved environment '%s/%s' to '%s/%s'", from.Name, oldName, to.Name, name)
+	return nil
+}
@@ -0,0 +1,150 @@
+package env
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/leodahal4/dev-kit/cli/project"
+	"github.com/leodahal4/dev-kit/cli/utils"
+	"github.com/leodahal4/dev-kit/config"
+	"github.com/spf13/cobra"
+)
+
+func NewListCommand() *cobra.Command {
+	listCmd := &cobra.Command{
+		Use:               "list [<project>]",
+		Aliases:           []string{"ls"},
+		Short:             "List the environments of every project or of one",
+		Args:              cobra.MaximumNArgs(1),
+		Annotations:       utils.ConfigOnly(),
+		ValidArgsFunction: utils.CompleteProjects,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			utils.ParseAndSaveCommand(cmd, args)
+		},
+		RunE: List,
+	}
+
+	utils.AddOutputFlag(listCmd)
+
+	return listCmd
+}
+
+func NewShowCommand() *cobra.Command {
+	showCmd := &cobra.Command{
+		Use:               "show <project>/<env>",
+		Short:             "Show an environment",
+		Args:              cobra.ExactArgs(1),
+		Annotations:       utils.ConfigOnly(),
+		ValidArgsFunction: utils.CompleteEnvironments,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			utils.ParseAndSaveCommand(cmd, args)
+		},
+		RunE: Show,
+	}
+
+	utils.AddOutputFlag(showCmd)
+
+	return showCmd
+}
+
+// envEntry is an environment listed in JSON, along with its project
+type envEntry struct {
+	Project string `json:"project"`
+	config.EnvironmentConfig
+}
+
+// List prints the environments of the project given as first argument, or of
+// every project
+func List(cmd *cobra.Command, args []string) error {
+	format, err := utils.OutputFormat(cmd)
+	if err != nil {
+		return err
+	}
+
+	cfg := config.GetConfig()
+	var projects []*config.ProjectConfig
+	if len(args) == 1 {
+		p, err := cfg.FindProject(args[0])
+		if err != nil {
+			return err
+		}
+		projects = append(projects, p)
+	} else {
+		for i := range cfg.Projects {
+			projects = append(projects, &cfg.Projects[i])
+		}
+	}
+
+	if format == utils.OutputJSON {
+		entries := []envEntry{}
+		for _, p := range projects {
+			for _, env := range p.Environments {
+				entries = append(entries, envEntry{Project: p.Name, EnvironmentConfig: env})
+			}
+		}
+		return utils.PrintJSON(cmd.OutOrStdout(), entries)
+	}
+	return project.PrintEnvironments(cmd.OutOrStdout(), projects, true)
+}
+
+// Show prints the environment referenced by the first argument
+func Show(cmd *cobra.Command, args []string) error {
+	format, err := utils.OutputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	p, env, err := config.GetConfig().FindEnvironment(args[0])
+	if err != nil {
+		return err
+	}
+	if format == utils.OutputJSON {
+		return utils.PrintJSON(cmd.OutOrStdout(), envEntry{Project: p.Name, EnvironmentConfig: *env})
+	}
+
+	command := env.Command
+	if command != "" && !env.Shell {
+		command = utils.JoinCommandLine(env.Command, env.Args)
+	}
+	if env.Shell {
+		command += " (shell)"
+	}
+	restart := env.Restart
+	if env.MaxRestarts > 0 {
+		restart = fmt.Sprintf("%s (at most %d times)", utils.Cell(restart), env.MaxRestarts)
+	}
+
+	w := utils.NewTable(cmd.OutOrStdout())
+	fmt.Fprintf(w, "Project:\t%s\n", p.Name)
+	fmt.Fprintf(w, "Name:\t%s\n", env.Name)
+	fmt.Fprintf(w, "Description:\t%s\n", utils.Cell(env.Description))
+	fmt.Fprintf(w, "Language:\t%s\n", utils.Cell(env.Language))
+	fmt.Fprintf(w, "Path:\t%s\n", utils.Cell(env.Path))
+	fmt.Fprintf(w, "Command:\t%s\n", utils.Cell(command))
+	fmt.Fprintf(w, "Build:\t%s\n", utils.Cell(env.Build))
+	fmt.Fprintf(w, "Test:\t%s\n", utils.Cell(env.Test))
+	var tasks []string
+	for _, task := range config.SortedTasks(p.EnvironmentTasks(env)) {
+		tasks = append(tasks, task.Name)
+	}
+	fmt.Fprintf(w, "Tasks:\t%s\n", utils.Cell(strings.Join(tasks, ", ")))
+	fmt.Fprintf(w, "Restart:\t%s\n", utils.Cell(restart))
+	fmt.Fprintf(w, "Depends on:\t%s\n", utils.Cell(strings.Join(env.DependsOn, ", ")))
+	fmt.Fprintf(w, "Ready:\t%s\n", utils.Cell(describeProbe(env.Ready)))
+	fmt.Fprintf(w, "Env files:\t%s\n", utils.Cell(strings.Join(env.EnvFiles, ", ")))
+	fmt.Fprintf(w, "Variables:\t%d\n", len(env.Env))
+	return w.Flush()
+}
+
+func describeProbe(probe config.ReadinessProbe) string {
+	var checks []string
+	if probe.TCP != "" {
+		checks = append(checks, "tcp "+probe.TCP)
+	}
+	if probe.HTTP != "" {
+		checks = append(checks, "http "+probe.HTTP)
+	}
+	if probe.Command != "" {
+		checks = append(checks, "command "+probe.Command)
+	}
+	return strings.Join(checks, ", ")
+}
@@ -0,0 +1,66 @@
+package env
+
+import (
+	"fmt"
+
+	"github.com/leodahal4/dev-kit/cli/secrets"
+	"github.com/leodahal4/dev-kit/cli/utils"
+	"github.com/leodahal4/dev-kit/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func NewRemoveCommand() *cobra.Command {
+	rmCmd := &cobra.Command{
+		Use:               "rm <project>/<env>",
+		Aliases:           []string{"remove"},
+		Short:             "Remove an environment",
+		Long:              "Remove an environment from its project along with its secrets, its files are left untouched. Environments which others depend on cannot be removed.",
+		Args:              cobra.ExactArgs(1),
+		Annotations:       utils.ConfigOnly(),
+		ValidArgsFunction: utils.CompleteEnvironments,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			utils.ParseAndSaveCommand(cmd, args)
+		},
+		RunE: Remove,
+	}
+
+	utils.AddConfirmFlag(rmCmd)
+
+	return rmCmd
+}
+
+// Remove removes the environment referenced by the first argument
+func Remove(cmd *cobra.Command, args []string) error {
+	cfg := config.GetConfig()
+	project, env, err := cfg.FindEnvironment(args[0])
+	if err != nil {
+		return err
+	}
+
+	name := env.Name
+	if dependents := project.Dependents(name); len(dependents) > 0 {
+		// fail before asking for a confirmation
+		return project.RemoveEnvironment(name)
+	}
+	confirmed, err := utils.Confirm(cmd, fmt.Sprintf("Remove environment '%s/%s'?", project.Name, name))
+	if err != nil || !confirmed {
+		return err
+	}
+	projectID := project.ID
+	err = config.Update(func(cfg *config.GlobalConfig) error {
+		project, err := cfg.FindProject(projectID)
+		if err != nil {
+			return err
+		}
+		return project.RemoveEnvironment(name)
+	})
+	if err != nil {
+		return err
+	}
+	if err := secrets.Forget(projectID, name); err != nil {
+		return err
+	}
+	logrus.Infof("Removed environment '%s/%s'", project.Name, name)
+	return nil
+}
@@ -0,0 +1,91 @@
+package env
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/leodahal4/dev-kit/cli/utils"
+	"github.com/leodahal4/dev-kit/config"
+	"github.com/spf13/cobra"
+)
+
+// secretKey matches variable names whose values are masked by "devkit env vars"
+var secretKey = regexp.MustCompile(`(?i)(pass(word|wd)?|secret|token|api_?key|private_?key|credential)`)
+
+func NewVarsCommand() *cobra.Command {
+	varsCmd := &cobra.Command{
+		Use:     "vars <project>/<env>",
+		Short:   "Print the variables of an environment",
+		Long:    "Print the variables devkit sets when running an environment, after loading its env files and interpolating ${VAR} references. Values of secret looking variables are masked.",
+		Example: "  devkit env vars shop/api\n  devkit env vars shop/api --show-secrets",
+		Args:    cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			utils.ParseAndSaveCommand(cmd, args)
+		},
+		ValidArgsFunction: utils.CompleteEnvironments,
+		RunE:              PrintVars,
+	}
+
+	varsCmd.Flags().Bool("show-secrets", false, "print secret looking values instead of masking them")
+	varsCmd.Flags().Bool("all", false, "also print the variables inherited from devkit")
+
+	return varsCmd
+}
+
+// PrintVars prints the resolved variables of the environment referenced by the
+// first argument
+func PrintVars(cmd *cobra.Command, args []string) error {
+	showSecrets, _ := cmd.Flags().GetBool("show-secrets")
+	all, _ := cmd.Flags().GetBool("all")
+
+	cfg := config.GetConfig()
+	project, env, err := cfg.FindEnvironment(args[0])
+	if err != nil {
+		return err
+	}
+
+	base := map[string]string{}
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		base[k] = v
+	}
+
+	vars, err := project.ResolveEnv(env, base)
+	if err != nil {
+		return err
+	}
+	if all {
+		for k, v := range base {
+			if _, ok := vars[k]; !ok {
+				vars[k] = v
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := cmd.OutOrStdout()
+	for _, k := range keys {
+		value := vars[k]
+		if !showSecrets && secretKey.MatchString(k) {
+			value = mask(value)
+		}
+		fmt.Fprintf(out, "%s=%s\n", k, value)
+	}
+	return nil
+}
+
+// mask hides a secret value, keeping only whether it is set
+func mask(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "********"
+}
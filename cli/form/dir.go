@@ -0,0 +1,156 @@
+package form
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// browserHeight is the number of directories listed at once by a DirField
+const browserHeight = 8
+
+// DirField is a path input which can browse the directories of the disk
+type DirField struct {
+	*TextField
+
+	browsing bool
+	dir      string
+	entries  []string
+	index    int
+	offset   int
+	err      error
+}
+
+// Dir adds a directory input pre-filled with value, ctrl+o opens a directory
+// browser starting at the typed path
+func (f *Form) Dir(label, value string, validate func(string) error) *DirField {
+	text := f.Text(label, value, validate)
+	field := &DirField{TextField: text}
+	f.fields[len(f.fields)-1] = field
+	return field
+}
+
+// ExistingDir is a validation failing unless value is a directory
+func ExistingDir(value string) error {
+	info, err := os.Stat(value)
+	if err != nil {
+		return fmt.Errorf("%s does not exist", value)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", value)
+	}
+	return nil
+}
+
+func (d *DirField) Capturing() bool { return d.browsing }
+
+func (d *DirField) Help() string {
+	if d.browsing {
+		return "↑/↓ move • → open • ← parent • enter choose • esc close"
+	}
+	return "ctrl+o browse"
+}
+
+func (d *DirField) Update(msg tea.Msg) tea.Cmd {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return d.TextField.Update(msg)
+	}
+	if !d.browsing {
+		if key.String() == "ctrl+o" {
+			start := d.Value()
+			if start == "" || ExistingDir(start) != nil {
+				start, _ = os.Getwd()
+			}
+			d.browsing = true
+			d.open(start)
+			return nil
+		}
+		return d.TextField.Update(msg)
+	}
+
+	switch key.String() {
+	case "esc":
+		d.browsing = false
+	case "up", "k":
+		d.scroll(-1)
+	case "down", "j":
+		d.scroll(1)
+	case "right", "l":
+		if d.index > 0 {
+			d.open(filepath.Join(d.dir, d.entries[d.index]))
+		}
+	case "left", "h", "backspace":
+		d.open(filepath.Dir(d.dir))
+	case "enter", " ":
+		chosen := d.dir
+		if d.index > 0 {
+			chosen = filepath.Join(d.dir, d.entries[d.index])
+		}
+		d.input.SetValue(chosen)
+		d.input.CursorEnd()
+		d.browsing = false
+	}
+	return nil
+}
+
+// open lists the directories below dir, the first entry standing for dir
+// itself
+func (d *DirField) open(dir string) {
+	d.dir, d.index, d.offset, d.err = dir, 0, 0, nil
+	d.entries = []string{"."}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		d.err = err
+		return
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	d.entries = append(d.entries, names...)
+}
+
+func (d *DirField) scroll(delta int) {
+	d.index = min(max(d.index+delta, 0), len(d.entries)-1)
+	if d.index < d.offset {
+		d.offset = d.index
+	}
+	if d.index >= d.offset+browserHeight {
+		d.offset = d.index - browserHeight + 1
+	}
+}
+
+func (d *DirField) View(focused bool) string {
+	view := d.TextField.View(focused)
+	if !d.browsing || !focused {
+		return view
+	}
+
+	var b strings.Builder
+	b.WriteString(view + "\n    " + labelStyle.Render(d.dir))
+	if d.err != nil {
+		b.WriteString("\n    " + errorStyle.Render(d.err.Error()))
+	}
+	end := min(d.offset+browserHeight, len(d.entries))
+	for i := d.offset; i < end; i++ {
+		name := d.entries[i] + "/"
+		if i == d.index {
+			b.WriteString("\n    " + focusStyle.Render("› "+name))
+		} else {
+			b.WriteString("\n      " + name)
+		}
+	}
+	if end < len(d.entries) {
+		b.WriteString("\n      " + labelStyle.Render(fmt.Sprintf("… %d more", len(d.entries)-end)))
+	}
+	return b.String()
+}
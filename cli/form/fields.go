@@ -0,0 +1,186 @@
+package form
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TextField is a single line text input
+type TextField struct {
+	label    string
+	input    textinput.Model
+	validate func(string) error
+}
+
+// Text adds a text input pre-filled with value. validate may be nil, it is
+// called on every change to show the problems of the value inline.
+func (f *Form) Text(label, value string, validate func(string) error) *TextField {
+	input := textinput.New()
+	input.Prompt = ""
+	input.SetValue(value)
+	input.CursorEnd()
+	field := &TextField{label: label, input: input, validate: validate}
+	f.add(field)
+	return field
+}
+
+// Required returns a validation failing on empty values, and otherwise
+// running the given validations
+func Required(validations ...func(string) error) func(string) error {
+	return func(value string) error {
+		if strings.TrimSpace(value) == "" {
+			return fmt.Errorf("a value is required")
+		}
+		for _, validate := range validations {
+			if err := validate(value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func (t *TextField) Value() string {
+	return strings.TrimSpace(t.input.Value())
+}
+
+func (t *TextField) Label() string   { return t.label }
+func (t *TextField) Focus() tea.Cmd  { return t.input.Focus() }
+func (t *TextField) Blur()           { t.input.Blur() }
+func (t *TextField) Capturing() bool { return false }
+func (t *TextField) Help() string    { return "" }
+
+func (t *TextField) Update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	t.input, cmd = t.input.Update(msg)
+	return cmd
+}
+
+func (t *TextField) View(bool) string {
+	return t.input.View()
+}
+
+func (t *TextField) Validate() error {
+	if t.validate == nil {
+		return nil
+	}
+	return t.validate(t.Value())
+}
+
+// ToggleField is a yes/no choice
+type ToggleField struct {
+	label string
+	value bool
+}
+
+// Toggle adds a yes/no choice
+func (f *Form) Toggle(label string, value bool) *ToggleField {
+	field := &ToggleField{label: label, value: value}
+	f.add(field)
+	return field
+}
+
+func (t *ToggleField) Value() bool {
+	return t.value
+}
+
+func (t *ToggleField) Label() string   { return t.label }
+func (t *ToggleField) Focus() tea.Cmd  { return nil }
+func (t *ToggleField) Blur()           {}
+func (t *ToggleField) Capturing() bool { return false }
+func (t *ToggleField) Validate() error { return nil }
+func (t *ToggleField) Help() string    { return "space/←/→ toggle" }
+
+func (t *ToggleField) Update(msg tea.Msg) tea.Cmd {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case " ", "left", "right", "h", "l":
+			t.value = !t.value
+		case "y":
+			t.value = true
+		case "n":
+			t.value = false
+		}
+	}
+	return nil
+}
+
+func (t *ToggleField) View(bool) string {
+	if t.value {
+		return "no / " + activeStyle.Render("yes")
+	}
+	return activeStyle.Render("no") + " / yes"
+}
+
+// Option is a choice of a SelectField
+type Option struct {
+	Label string
+	Value string
+}
+
+// SelectField is a choice among a list of options
+type SelectField struct {
+	label   string
+	options []Option
+	index   int
+}
+
+// Select adds a choice among options, starting with the one whose value is
+// selected
+func (f *Form) Select(label string, options []Option, selected string) *SelectField {
+	field := &SelectField{label: label, options: options}
+	for i, option := range options {
+		if option.Value == selected {
+			field.index = i
+		}
+	}
+	f.add(field)
+	return field
+}
+
+// Value returns the value of the selected option, empty when there is none
+func (s *SelectField) Value() string {
+	if len(s.options) == 0 {
+		return ""
+	}
+	return s.options[s.index].Value
+}
+
+func (s *SelectField) Label() string   { return s.label }
+func (s *SelectField) Focus() tea.Cmd  { return nil }
+func (s *SelectField) Blur()           {}
+func (s *SelectField) Capturing() bool { return false }
+func (s *SelectField) Help() string    { return "←/→ choose" }
+
+func (s *SelectField) Validate() error {
+	if len(s.options) == 0 {
+		return fmt.Errorf("there is nothing to choose from")
+	}
+	return nil
+}
+
+func (s *SelectField) Update(msg tea.Msg) tea.Cmd {
+	if key, ok := msg.(tea.KeyMsg); ok && len(s.options) > 0 {
+		switch key.String() {
+		case "left", "h":
+			s.index = (s.index + len(s.options) - 1) % len(s.options)
+		case "right", "l", " ":
+			s.index = (s.index + 1) % len(s.options)
+		}
+	}
+	return nil
+}
+
+func (s *SelectField) View(focused bool) string {
+	if len(s.options) == 0 {
+		return ""
+	}
+	label := activeStyle.Render(s.options[s.index].Label)
+	if focused && len(s.options) > 1 {
+		return fmt.Sprintf("‹ %s › %s", label, labelStyle.Render(fmt.Sprintf("%d/%d", s.index+1, len(s.options))))
+	}
+	return label
+}
@@ -0,0 +1,182 @@
+package form
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ErrCancelled is returned by Run when the user leaves the form
+var ErrCancelled = errors.New("cancelled")
+
+var (
+	titleStyle  = lipgloss.NewStyle().Bold(true).MarginBottom(1)
+	labelStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+	focusStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+	errorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	helpStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).MarginTop(1)
+	activeStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Bold(true)
+)
+
+// Field is an input of a form
+type Field interface {
+	Label() string
+	Focus() tea.Cmd
+	Blur()
+	// Update handles the messages received while the field is focused
+	Update(msg tea.Msg) tea.Cmd
+	// Capturing reports whether the field handles the navigation keys itself,
+	// such as while a list is open
+	Capturing() bool
+	View(focused bool) string
+	// Validate returns the error of the current value
+	Validate() error
+	Help() string
+}
+
+// Form asks for the values of a list of fields at once. It is a tea.Model,
+// fields are added with Text, Toggle, Select and Dir, and read once Run
+// returned.
+type Form struct {
+	title     string
+	fields    []Field
+	errs      []error
+	focus     int
+	done      bool
+	cancelled bool
+}
+
+func New(title string) *Form {
+	return &Form{title: title}
+}
+
+func (f *Form) SetTitle(title string) {
+	f.title = title
+}
+
+func (f *Form) add(field Field) {
+	f.fields = append(f.fields, field)
+	f.errs = append(f.errs, nil)
+}
+
+// Run shows the form until it is submitted or cancelled
+func (f *Form) Run() error {
+	if len(f.fields) == 0 {
+		return nil
+	}
+	if _, err := tea.NewProgram(f).Run(); err != nil {
+		return fmt.Errorf("error running the form: %v", err)
+	}
+	if f.cancelled {
+		return ErrCancelled
+	}
+	return nil
+}
+
+func (f *Form) Init() tea.Cmd {
+	return f.fields[f.focus].Focus()
+}
+
+func (f *Form) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	field := f.fields[f.focus]
+	key, isKey := msg.(tea.KeyMsg)
+	if !isKey {
+		return f, field.Update(msg)
+	}
+
+	if key.String() == "ctrl+c" {
+		f.cancelled = true
+		return f, tea.Quit
+	}
+	if field.Capturing() {
+		cmd := field.Update(msg)
+		f.errs[f.focus] = field.Validate()
+		return f, cmd
+	}
+
+	switch key.String() {
+	case "esc":
+		f.cancelled = true
+		return f, tea.Quit
+	case "tab", "down":
+		return f, f.move(1)
+	case "shift+tab", "up":
+		return f, f.move(-1)
+	case "enter":
+		if f.focus < len(f.fields)-1 {
+			return f, f.move(1)
+		}
+		return f, f.submit()
+	}
+
+	cmd := field.Update(msg)
+	f.errs[f.focus] = field.Validate()
+	return f, cmd
+}
+
+// move focuses the field delta positions away from the focused one
+func (f *Form) move(delta int) tea.Cmd {
+	f.errs[f.focus] = f.fields[f.focus].Validate()
+	next := f.focus + delta
+	if next < 0 || next >= len(f.fields) {
+		return nil
+	}
+	return f.focusField(next)
+}
+
+func (f *Form) focusField(i int) tea.Cmd {
+	f.fields[f.focus].Blur()
+	f.focus = i
+	return f.fields[i].Focus()
+}
+
+// submit validates every field, as they may depend on each other, and quits
+// unless one of them is invalid
+func (f *Form) submit() tea.Cmd {
+	invalid := -1
+	for i, field := range f.fields {
+		f.errs[i] = field.Validate()
+		if f.errs[i] != nil && invalid < 0 {
+			invalid = i
+		}
+	}
+	if invalid >= 0 {
+		return f.focusField(invalid)
+	}
+	f.fields[f.focus].Blur()
+	f.done = true
+	return tea.Quit
+}
+
+func (f *Form) View() string {
+	width := 0
+	for _, field := range f.fields {
+		width = max(width, lipgloss.Width(field.Label()))
+	}
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(f.title) + "\n")
+	for i, field := range f.fields {
+		focused := i == f.focus && !f.done && !f.cancelled
+		marker := "  "
+		if focused {
+			marker = focusStyle.Render("› ")
+		}
+		label := labelStyle.Width(width + 2).Render(field.Label())
+		b.WriteString(marker + label + field.View(focused) + "\n")
+		if f.errs[i] != nil && !f.done {
+			b.WriteString(strings.Repeat(" ", width+4) + errorStyle.Render("✖ "+f.errs[i].Error()) + "\n")
+		}
+	}
+	if !f.done && !f.cancelled {
+		help := "tab/↓ next • shift+tab/↑ previous • enter submit • esc cancel"
+		if extra := f.fields[f.focus].Help(); extra != "" {
+			help = extra + " • " + help
+		}
+		b.WriteString(helpStyle.Render(help) + "\n")
+	}
+	return b.String()
+}
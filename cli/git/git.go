@@ -0,0 +1,150 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Available checks that the git binary can be found
+func Available() error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return errors.New("git is not installed or not in PATH, it is needed to work with repositories")
+	}
+	return nil
+}
+
+// Output runs git in dir and returns its output without the trailing newline.
+// The error holds what git printed on stderr.
+func Output(ctx context.Context, dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	// the output is captured, and the commands may run several at once: fail
+	// instead of asking for credentials
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %v", args[0], err)
+	}
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
+
+// Clone clones url into dir, checking out branch unless it is empty. The
+// progress of git is written to progress.
+func Clone(ctx context.Context, url, branch, dir string, progress io.Writer) error {
+	args := []string{"clone"}
+	if branch != "" {
+		args = append(args, "--branch", branch)
+	}
+	args = append(args, "--", url, dir)
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stdout, cmd.Stderr = progress, progress
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error cloning %s: %v", url, err)
+	}
+	return nil
+}
+
+// IsRepository reports whether dir is the top directory of a git repository
+func IsRepository(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+// RemoteURL returns the URL of the origin remote of the repository in dir
+func RemoteURL(ctx context.Context, dir string) (string, error) {
+	return Output(ctx, dir, "remote", "get-url", "origin")
+}
+
+// Status is the state of the working tree of a repository
+type Status struct {
+	Branch string `json:"branch"`
+
+	// Upstream is the branch tracked, empty when there is none, and Ahead and
+	// Behind count the commits not pushed and not pulled yet
+	Upstream string `json:"upstream,omitempty"`
+	Ahead    int    `json:"ahead"`
+	Behind   int    `json:"behind"`
+
+	// Changed counts the files modified, staged or not tracked
+	Changed int `json:"changed"`
+
+	// Commit is the last commit, empty in a repository without commits
+	Commit Commit `json:"commit"`
+}
+
+// Commit describes a commit for humans
+type Commit struct {
+	Hash    string `json:"hash"`
+	Subject string `json:"subject"`
+	When    string `json:"when"`
+}
+
+// Dirty reports whether the working tree has changes
+func (s Status) Dirty() bool {
+	return s.Changed > 0
+}
+
+// TopLevel returns the root of the repository holding dir
+func TopLevel(ctx context.Context, dir string) (string, error) {
+	root, err := Output(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Clean(filepath.FromSlash(root)), nil
+}
+
+// GetStatus returns the branch, the commits ahead and behind the upstream
+// branch, the changes and the last commit of the repository holding dir
+func GetStatus(ctx context.Context, dir string) (Status, error) {
+	output, err := Output(ctx, dir, "status", "--porcelain=v2", "--branch")
+	if err != nil {
+		return Status{}, err
+	}
+
+	var status Status
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "# branch.head "):
+			status.Branch = strings.TrimPrefix(line, "# branch.head ")
+		case strings.HasPrefix(line, "# branch.upstream "):
+			status.Upstream = strings.TrimPrefix(line, "# branch.upstream ")
+		case strings.HasPrefix(line, "# branch.ab "):
+			_, _ = fmt.Sscanf(strings.TrimPrefix(line, "# branch.ab "), "+%d -%d", &status.Ahead, &status.Behind)
+		case strings.HasPrefix(line, "#"):
+		default:
+			status.Changed++
+		}
+	}
+	if status.Branch == "(detached)" {
+		if hash, err := Output(ctx, dir, "rev-parse", "--short", "HEAD"); err == nil {
+			status.Branch = "detached at " + hash
+		}
+	}
+
+	// fails in a repository without commits
+	if log, err := Output(ctx, dir, "log", "-1", "--format=%h%x00%s%x00%cr"); err == nil {
+		if parts := strings.SplitN(log, "\x00", 3); len(parts) == 3 {
+			status.Commit = Commit{Hash: parts[0], Subject: parts[1], When: parts[2]}
+		}
+	}
+	return status, nil
+}
+
+// Head returns the commit checked out in dir, empty without commits
+func Head(ctx context.Context, dir string) string {
+	head, _ := Output(ctx, dir, "rev-parse", "HEAD")
+	return head
+}
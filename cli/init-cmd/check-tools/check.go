@@ -1,36 +1,216 @@
 package checktools
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
 	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	usg "github.com/julienroland/usg"
+	"github.com/leodahal4/dev-kit/cli/tools"
+	"github.com/leodahal4/dev-kit/cli/utils"
 	"github.com/leodahal4/dev-kit/config"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 func NewToolsCheckerCommand() *cobra.Command {
 	checkToolsCmd := &cobra.Command{
 		Use:   "check",
 		Short: "Check all needed tools",
-		Long:  "Initialize any project with the basic configuration",
-		RunE:  checkTools,
+		Long: `Check that the tools used by devkit are installed and recent enough. Every tool
+is looked up on PATH and its version compared with the configured minimum, devkit
+can only be used once all the required tools pass.
+
+Projects additionally need the tools of their environments, derived from their
+language and the manifests (go.mod, package.json, ...) found in their path. A
+project can only be used once its own tools passed.`,
+		Example: "  devkit init check\n  devkit init check --project shop",
+		RunE:    checkTools,
 	}
 
+	checkToolsCmd.Flags().String("project", "", "only check the tools of this project besides the global ones")
+	_ = checkToolsCmd.RegisterFlagCompletionFunc("project", utils.CompleteProjects)
+
 	return checkToolsCmd
 }
 
-// InitProject handles the initialization of a new project
+// checkTools checks the global tools and the tools of the projects, and records
+// whether the required ones passed
 func checkTools(cmd *cobra.Command, args []string) error {
-	// Create a terminal providerA
-	s := spinner.New()
-	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
-	logrus.Infof("%v Checking Docker", usg.Get.Tick)
-	logrus.Infof("%v Checking kind", usg.Get.Cross)
-	logrus.Infof("Checking golang")
 	cfg := config.GetConfig()
+	ref, _ := cmd.Flags().GetString("project")
+
+	var projects []*config.ProjectConfig
+	if ref != "" {
+		project, err := cfg.FindProject(ref)
+		if err != nil {
+			return err
+		}
+		projects = append(projects, project)
+	} else {
+		for i := range cfg.Projects {
+			projects = append(projects, &cfg.Projects[i])
+		}
+	}
+
+	// every distinct tool is only checked once
+	list := cfg.CheckedTools()
+	for _, project := range projects {
+		project.RefreshTools()
+		for _, tool := range project.Tools {
+			if !slices.ContainsFunc(list, func(t config.ToolRequirement) bool { return toolKey(t) == toolKey(tool) }) {
+				list = append(list, tool)
+			}
+		}
+	}
+	results := map[string]tools.Result{}
+	for _, r := range runChecks(cmd.Context(), list) {
+		results[toolKey(r.Tool)] = r
+	}
+
+	var failed []string
 	cfg.CHECKED_TOOLS = true
-	config.UpdateConfig(cfg)
+	for _, tool := range cfg.CheckedTools() {
+		if !report(tool, results[toolKey(tool)]) {
+			cfg.CHECKED_TOOLS = false
+			failed = append(failed, tool.Name)
+		}
+	}
+	for _, project := range projects {
+		if len(project.Tools) == 0 {
+			project.ToolsChecked = true
+			continue
+		}
+		logrus.Infof("Project %s:", project.Name)
+		project.ToolsChecked = true
+		for _, tool := range project.Tools {
+			if !report(tool, results[toolKey(tool)]) {
+				project.ToolsChecked = false
+				failed = append(failed, fmt.Sprintf("%s (%s)", tool.Name, project.Name))
+			}
+		}
+	}
+
+	// only the outcome of the checks is saved, the rest of the configuration may
+	// have been changed by another devkit while they ran
+	err := config.Update(func(latest *config.GlobalConfig) error {
+		latest.CHECKED_TOOLS = cfg.CHECKED_TOOLS
+		for _, project := range projects {
+			if p, err := latest.FindProject(project.ID); err == nil {
+				p.Tools, p.ToolsChecked = project.Tools, project.ToolsChecked
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("required tools are missing or outdated: %s", strings.Join(failed, ", "))
+	}
+	logrus.Infof("All required tools are available")
 	return nil
 }
+
+// toolKey identifies the check of a tool
+func toolKey(t config.ToolRequirement) string {
+	return strings.Join(append([]string{t.Executable(), t.MinVersion}, t.VersionArgs...), "\x00")
+}
+
+// runChecks checks the tools, showing a spinner while they run when stderr is
+// a terminal
+func runChecks(ctx context.Context, list []config.ToolRequirement) []tools.Result {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !term.IsTerminal(int(os.Stderr.Fd())) {
+		return tools.CheckAll(ctx, list, nil)
+	}
+
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+	model := checkModel{spinner: s}
+	for _, tool := range list {
+		model.pending = append(model.pending, tool.Name)
+	}
+
+	program := tea.NewProgram(model, tea.WithOutput(os.Stderr), tea.WithInput(nil))
+	var results []tools.Result
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		results = tools.CheckAll(ctx, list, func(r tools.Result) {
+			program.Send(toolCheckedMsg(r.Tool.Name))
+		})
+		program.Send(checksDoneMsg{})
+	}()
+	if _, err := program.Run(); err != nil {
+		logrus.Debugf("spinner: %v", err)
+	}
+	<-done
+	return results
+}
+
+// report prints the outcome of checking a tool, and returns false when a
+// required tool did not pass
+func report(tool config.ToolRequirement, r tools.Result) bool {
+	switch {
+	case r.Passed():
+		logrus.Infof("%s %s %s (%s)", usg.Get.Tick, tool.Name, r.Version, r.Path)
+	case tool.Required:
+		logrus.Errorf("%s %s: %v", usg.Get.Cross, tool.Name, r.Err)
+		return false
+	default:
+		logrus.Warnf("%s %s: %v (optional)", usg.Get.Warning, tool.Name, r.Err)
+	}
+	return true
+}
+
+type toolCheckedMsg string
+
+type checksDoneMsg struct{}
+
+// checkModel shows a spinner next to the tools still being checked
+type checkModel struct {
+	spinner spinner.Model
+	pending []string
+}
+
+func (m checkModel) Init() tea.Cmd {
+	return m.spinner.Tick
+}
+
+func (m checkModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case toolCheckedMsg:
+		for i, name := range m.pending {
+			if name == string(msg) {
+				m.pending = append(m.pending[:i:i], m.pending[i+1:]...)
+				break
+			}
+		}
+		return m, nil
+	case checksDoneMsg:
+		m.pending = nil
+		return m, tea.Quit
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m checkModel) View() string {
+	if len(m.pending) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s Checking %s\n", m.spinner.View(), strings.Join(m.pending, ", "))
+}
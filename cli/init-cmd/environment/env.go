@@ -3,11 +3,15 @@ package environment
 import (
 	"context"
 	"fmt"
-	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/leodahal4/dev-kit/cli/detect"
+	"github.com/leodahal4/dev-kit/cli/form"
 	"github.com/leodahal4/dev-kit/cli/utils"
 	"github.com/leodahal4/dev-kit/config"
 	pb "github.com/leodahal4/dev-kit/protos"
+	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
 
 	"github.com/spf13/cobra"
@@ -17,13 +21,28 @@ func NewEnvCommand() *cobra.Command {
 	EnvCmd := &cobra.Command{
 		Use:   "env",
 		Short: "Create new Env",
-		Long:  "Initialize any Env with the basic configuration",
+		Long: `Initialize any Env with the basic configuration. Values which are not given as
+flags are prompted for, the language and commands detected in the path of the
+environment are suggested.`,
+		Example: "  devkit init env\n  devkit init env --project shop --name api --path ./api --yes",
 		PreRun: func(cmd *cobra.Command, args []string) {
 			utils.ParseAndSaveCommand(cmd, args)
 		},
 		RunE: InitEnv,
 	}
 	EnvCmd.Flags().BoolP("server", "s", false, "print DevKit version")
+	EnvCmd.Flags().String("project", "", "ID or name of the project of the environment")
+	EnvCmd.Flags().String("name", "", "name of the environment")
+	EnvCmd.Flags().String("description", "", "description of the environment")
+	EnvCmd.Flags().String("path", "", "directory of the environment, . for the current one")
+	EnvCmd.Flags().String("language", "", "language of the environment, detected from its path by default")
+	EnvCmd.Flags().String("command", "", "command line running the environment, detected from its path by default")
+	EnvCmd.Flags().Bool("shell", false, "run the command through the system shell")
+	EnvCmd.Flags().String("build", "", "command line building the environment")
+	EnvCmd.Flags().String("test", "", "command line testing the environment")
+	utils.AddNonInteractiveFlags(EnvCmd)
+	_ = EnvCmd.RegisterFlagCompletionFunc("project", utils.CompleteProjects)
+	_ = EnvCmd.MarkFlagDirname("path")
 
 	return EnvCmd
 }
@@ -32,48 +51,64 @@ func NewEnvCommand() *cobra.Command {
 func InitEnv(cmd *cobra.Command, args []string) error {
 	serverFlag, _ := cmd.Flags().GetBool("server")
 	if serverFlag {
-		return createEnvOnServer()
-	}
-	// Create a terminal provider
-	projectID := utils.AskInput("Project ID: ", "1")
-	EnvName := utils.AskInput("Name: ", "Sample Env")
-	EnvDescription := utils.AskInput("Description: ", " ")
-	EnvPath := utils.AskInput("Path: ", "/path/to/env")
-
-	// Check if the user entered '.' and resolve it to the current working directory
-	if EnvPath == "." {
-		var err error
-		EnvPath, err = os.Getwd()
-		if err != nil {
-			return fmt.Errorf("error getting current working directory: %v", err)
-		}
+		return createEnvOnServer(cmd)
 	}
 
 	cfg := config.GetConfig()
+	var options []form.Option
+	for _, p := range cfg.Projects {
+		options = append(options, form.Option{Label: fmt.Sprintf("%s (ID %s)", p.Name, p.ID), Value: p.ID})
+	}
+	selected := ""
+	if len(cfg.Projects) == 1 {
+		selected = cfg.Projects[0].ID
+	}
 
-	// Validate for duplicate Env and path
-	if err := cfg.ValidateEnv(projectID, EnvName, EnvPath); err != nil {
+	prompt := utils.NewPrompter(cmd)
+	projectID := prompt.Select("project", "Project", options, selected, func(ref string) (string, error) {
+		project, err := cfg.FindProject(ref)
+		if err != nil {
+			return "", err
+		}
+		return project.ID, nil
+	})
+	env := askEnv(prompt, func(name string) error { return cfg.ValidateEnvName(projectID(), name) }, form.ExistingDir)
+	if err := prompt.Run("New environment"); err != nil {
 		return err
 	}
+	environment := env()
 
-	// Find the project and append the new environment
-	for i, project := range cfg.Projects {
-		if project.ID == projectID {
-			cfg.Projects[i].Environments = append(cfg.Projects[i].Environments, config.EnvironmentConfig{
-				Name:        EnvName,
-				Description: EnvDescription,
-				Path:        EnvPath,
-			})
-			break
-		}
+	// Resolve '.' and other relative paths against the current working directory
+	var err error
+	if environment.Path, err = filepath.Abs(environment.Path); err != nil {
+		return fmt.Errorf("error resolving path: %v", err)
+	}
+	if err := askDetails(prompt, &environment); err != nil {
+		return err
 	}
 
-	cfg.UpdateConfig()
-	return nil
+	return config.Update(func(cfg *config.GlobalConfig) error {
+		project, err := cfg.FindProject(projectID())
+		if err != nil {
+			return err
+		}
+
+		// Validate for duplicate Env and path
+		if err := cfg.ValidateEnv(project.ID, environment.Name, environment.Path); err != nil {
+			return err
+		}
+
+		project.Environments = append(project.Environments, environment)
+		if project.RefreshTools() {
+			logrus.Infof("Project '%s' now needs %s, run \"devkit init check --project %s\" before using it",
+				project.Name, strings.Join(project.ToolNames(), ", "), project.Name)
+		}
+		return nil
+	})
 }
 
 // createEnvOnServer handles the creation of an environment using the gRPC server
-func createEnvOnServer() error {
+func createEnvOnServer(cmd *cobra.Command) error {
 	// Connect to the gRPC server
 	conn, err := grpc.Dial("localhost:50051", grpc.WithInsecure())
 	if err != nil {
@@ -84,22 +119,34 @@ func createEnvOnServer() error {
 	client := pb.NewConfigServiceClient(conn)
 
 	// Gather environment details
-	projectID := utils.AskInput("Project ID: ", "1")
-	EnvName := utils.AskInput("Name: ", "Sample Env")
-	EnvDescription := utils.AskInput("Description: ", " ")
-	EnvPath := utils.AskInput("Path: ", "/path/to/env")
+	prompt := utils.NewPrompter(cmd)
+	projectID := prompt.Text("project", "Project ID", "1", form.Required())
+	env := askEnv(prompt, nil, nil)
+	if err := prompt.Run("New environment"); err != nil {
+		return err
+	}
+	details := env()
+	if err := askDetails(prompt, &details); err != nil {
+		return err
+	}
 
 	// Create the environment on the server
-	env := &pb.EnvironmentConfig{
-		Name:        EnvName,
-		Description: EnvDescription,
-		Path:        EnvPath,
+	environment := &pb.EnvironmentConfig{
+		Name:        details.Name,
+		Description: details.Description,
+		Language:    details.Language,
+		Path:        details.Path,
+		Command:     details.Command,
+		Args:        details.Args,
+		Shell:       details.Shell,
+		Build:       details.Build,
+		Test:        details.Test,
 	}
 
 	// Call the gRPC method to create the environment
 	_, err = client.CreateEnvironment(context.Background(), &pb.CreateEnvironmentRequest{
-		ProjectId:   projectID,
-		Environment: env,
+		ProjectId:   projectID(),
+		Environment: environment,
 	})
 	if err != nil {
 		return fmt.Errorf("error creating environment on server: %v", err)
@@ -108,3 +155,56 @@ func createEnvOnServer() error {
 	fmt.Println("Environment created successfully on server.")
 	return nil
 }
+
+// askEnv declares the name, description and path of a new environment, with
+// optional validations of the name and path
+func askEnv(prompt *utils.Prompter, validateName, validatePath func(string) error) func() config.EnvironmentConfig {
+	name := prompt.Text("name", "Name", "", form.Required(optional(validateName)...))
+	description := prompt.Text("description", "Description", "", nil)
+	path := prompt.Dir("path", "Path", "", form.Required(optional(validatePath)...))
+	return func() config.EnvironmentConfig {
+		return config.EnvironmentConfig{Name: name(), Description: description(), Path: path()}
+	}
+}
+
+func optional(validate func(string) error) []func(string) error {
+	if validate == nil {
+		return nil
+	}
+	return []func(string) error{validate}
+}
+
+// askDetails detects the language of the environment in its path and asks for
+// its language and commands, suggesting the detected ones
+func askDetails(prompt *utils.Prompter, env *config.EnvironmentConfig) error {
+	detected, ok := detect.Detect(env.Path)
+	title := "Running " + env.Name
+	if ok {
+		description := detected.Language
+		if detected.Framework != "" {
+			description += ", " + detected.Framework
+		}
+		if detected.Tool != "" && detected.Tool != detected.Language {
+			description += ", " + detected.Tool
+		}
+		if prompt.Interactive() {
+			title += " (detected " + description + ")"
+		} else {
+			logrus.Infof("Detected %s in %s", description, env.Path)
+		}
+	}
+
+	language := prompt.Text("language", "Language", detected.Language, nil)
+	command := prompt.Text("command", "Command (empty for language default)", detected.Run, nil)
+	// the command is known by now when it was given as a flag
+	shell := prompt.Bool("shell", "Run the command through a shell", strings.ContainsAny(command(), "|&;<>$`"))
+	build := prompt.Text("build", "Build command", detected.Build, nil)
+	test := prompt.Text("test", "Test command", detected.Test, nil)
+	if err := prompt.Run(title); err != nil {
+		return err
+	}
+
+	env.Language, env.Build, env.Test = language(), build(), test()
+	env.Command, env.Args, env.Shell = utils.ParseCommandLine(command(), shell())
+	return nil
+}
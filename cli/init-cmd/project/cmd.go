@@ -3,6 +3,7 @@ package project
 import (
 	"fmt"
 
+	"github.com/leodahal4/dev-kit/cli/form"
 	"github.com/leodahal4/dev-kit/cli/utils"
 	"github.com/leodahal4/dev-kit/config"
 
@@ -13,37 +14,47 @@ func NewProjectCommand() *cobra.Command {
 	projectCmd := &cobra.Command{
 		Use:   "project",
 		Short: "Create new project",
-		Long:  "Initialize any project with the basic configuration",
+		Long: `Initialize any project with the basic configuration. Values which are not given
+as flags are prompted for.`,
+		Example: "  devkit init project\n  devkit init project --name shop --microservice --yes",
 		PreRun: func(cmd *cobra.Command, args []string) {
 			utils.ParseAndSaveCommand(cmd, args)
 		},
 		RunE: InitProject,
 	}
 
+	projectCmd.Flags().String("name", "", "name of the project")
+	projectCmd.Flags().String("description", "", "description of the project")
+	projectCmd.Flags().Bool("microservice", false, "the project follows a microservice architecture")
+	utils.AddNonInteractiveFlags(projectCmd)
+
 	return projectCmd
 }
 
 // InitProject handles the initialization of a new project
 func InitProject(cmd *cobra.Command, args []string) error {
-	// Create a terminal provider
-	projectName := utils.AskInput("Name: ", "Sample Project")
-	projectDescription := utils.AskInput("Description: ", " ")
-	isMicroserviceUser := utils.AskInput("Is this a microservice architecture ? (yes/no): ", "no")
-	isMicroservice := isMicroserviceUser == "yes" || isMicroserviceUser == "y"
-
 	cfg := config.GetConfig()
 
 	// Validate for duplicate project
-	if err := cfg.ValidateProject(projectName); err != nil {
+	prompt := utils.NewPrompter(cmd)
+	name := prompt.Text("name", "Name", "", form.Required(cfg.ValidateProject))
+	description := prompt.Text("description", "Description", "", nil)
+	isMicroservice := prompt.Bool("microservice", "Microservice architecture", false)
+	if err := prompt.Run("New project"); err != nil {
 		return err
 	}
 
-	cfg.Projects = append(cfg.Projects, config.ProjectConfig{
-		ID:             fmt.Sprintf("%d", cfg.GetProjectNewId()),
-		Name:           projectName,
-		Description:    projectDescription,
-		IsMicroservice: isMicroservice,
+	return config.Update(func(cfg *config.GlobalConfig) error {
+		// another devkit may have created the same project in the meantime
+		if err := cfg.ValidateProject(name()); err != nil {
+			return err
+		}
+		cfg.Projects = append(cfg.Projects, config.ProjectConfig{
+			ID:             fmt.Sprintf("%d", cfg.GetProjectNewId()),
+			Name:           name(),
+			Description:    description(),
+			IsMicroservice: isMicroservice(),
+		})
+		return nil
 	})
-	cfg.UpdateConfig()
-	return nil
 }
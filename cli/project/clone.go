@@ -0,0 +1,322 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/leodahal4/dev-kit/cli/detect"
+	"github.com/leodahal4/dev-kit/cli/git"
+	"github.com/leodahal4/dev-kit/cli/utils"
+	"github.com/leodahal4/dev-kit/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func NewCloneCommand() *cobra.Command {
+	cloneCmd := &cobra.Command{
+		Use:   "clone [<repository-url-or-path>...]",
+		Short: "Clone repositories and register them as environments",
+		Long: `Clone one or more git repositories into the directory of the project in the
+workspace, set with the workspace setting, and register each of them as an
+environment of the project with the language and commands detected in it. The
+project is created when it does not exist yet.
+
+The repositories making up a project, such as the services of a microservice
+project, can be listed in a manifest:
+
+  project: shop
+  microservice: true
+  repositories:
+    - url: git@github.com:shop/api.git
+    - url: git@github.com:shop/web.git
+      branch: develop
+      name: frontend
+
+The manifest of the project is written to ` + config.ManifestFileName + ` in its directory,
+and printed by "devkit project manifest". Repositories already cloned and
+registered are skipped, so cloning a manifest again only adds the new ones.`,
+		Example:     "  devkit project clone git@github.com:shop/api.git --project shop\n  devkit project clone --manifest shop.yaml",
+		Annotations: utils.ConfigOnly(),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			utils.ParseAndSaveCommand(cmd, args)
+		},
+		RunE: Clone,
+	}
+
+	cloneCmd.Flags().String("project", "", "ID or name of the project, created when it does not exist")
+	cloneCmd.Flags().StringP("manifest", "m", "", "manifest listing the repositories to clone")
+	cloneCmd.Flags().StringP("branch", "b", "", "branch to check out instead of the default one")
+	cloneCmd.Flags().String("name", "", "name of the environment, the name of the repository by default")
+	_ = cloneCmd.RegisterFlagCompletionFunc("project", utils.CompleteProjects)
+	_ = cloneCmd.MarkFlagFilename("manifest", "yaml", "yml")
+
+	return cloneCmd
+}
+
+func NewManifestCommand() *cobra.Command {
+	manifestCmd := &cobra.Command{
+		Use:   "manifest <project>",
+		Short: "Print the manifest of the repositories of a project",
+		Long: `Print the manifest listing the repositories the environments of a project were
+cloned from, which "devkit project clone --manifest" clones again on another
+machine.`,
+		Args:              cobra.ExactArgs(1),
+		Annotations:       utils.ConfigOnly(),
+		ValidArgsFunction: utils.CompleteProjects,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			utils.ParseAndSaveCommand(cmd, args)
+		},
+		RunE: PrintManifest,
+	}
+
+	utils.AddOutputFlag(manifestCmd)
+
+	return manifestCmd
+}
+
+// Clone clones the repositories given as arguments or listed by the manifest
+// and registers them as environments of the project
+func Clone(cmd *cobra.Command, args []string) error {
+	if err := git.Available(); err != nil {
+		return err
+	}
+	manifest, err := cloneManifest(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	cfg := config.GetConfig()
+	existing, err := cfg.FindProject(manifest.Project)
+	switch {
+	case err == nil:
+		manifest.Project = existing.Name
+	case !errors.Is(err, config.ErrProjectNotFound):
+		return err
+	default:
+		// checked before cloning anything into the directory of the project
+		if err := cfg.ValidateProject(manifest.Project); err != nil {
+			return err
+		}
+	}
+	dir, err := cfg.WorkspacePath(manifest.Project)
+	if err != nil {
+		return err
+	}
+
+	var environments []config.EnvironmentConfig
+	var cloneErr error
+	for _, repo := range manifest.Repositories {
+		env, err := cloneRepository(cmd, existing, dir, repo)
+		if err != nil {
+			// the repositories cloned so far are still registered
+			cloneErr = err
+			break
+		}
+		if env != nil {
+			environments = append(environments, *env)
+		}
+	}
+	if len(environments) == 0 {
+		return cloneErr
+	}
+
+	var saved *config.ProjectConfig
+	err = config.Update(func(cfg *config.GlobalConfig) error {
+		project, err := cfg.FindProject(manifest.Project)
+		if errors.Is(err, config.ErrProjectNotFound) {
+			if err := cfg.ValidateProject(manifest.Project); err != nil {
+				return err
+			}
+			cfg.Projects = append(cfg.Projects, config.ProjectConfig{
+				ID:          fmt.Sprintf("%d", cfg.GetProjectNewId()),
+				Name:        manifest.Project,
+				Description: manifest.Description,
+			})
+			project = &cfg.Projects[len(cfg.Projects)-1]
+			logrus.Infof("Created project '%s' (ID %s)", project.Name, project.ID)
+		} else if err != nil {
+			return err
+		}
+		for _, env := range environments {
+			if err := cfg.ValidateEnv(project.ID, env.Name, env.Path); err != nil {
+				return err
+			}
+			project.Environments = append(project.Environments, env)
+			logrus.Infof("Registered %s as environment '%s' of project '%s'", env.Path, env.Name, project.Name)
+		}
+		if manifest.Microservice || len(project.Environments) > 1 {
+			project.IsMicroservice = true
+		}
+		if project.RefreshTools() {
+			logrus.Infof("Project '%s' now needs %s, run \"devkit init check --project %s\" before using it",
+				project.Name, strings.Join(project.ToolNames(), ", "), project.Name)
+		}
+		saved = project
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := writeManifest(saved, dir); err != nil {
+		return err
+	}
+	return cloneErr
+}
+
+// cloneManifest returns the repositories to clone, from the manifest given
+// with --manifest or from the arguments
+func cloneManifest(cmd *cobra.Command, args []string) (*config.Manifest, error) {
+	projectRef, _ := cmd.Flags().GetString("project")
+	manifestFile, _ := cmd.Flags().GetString("manifest")
+	branch, _ := cmd.Flags().GetString("branch")
+	name, _ := cmd.Flags().GetString("name")
+
+	manifest := &config.Manifest{}
+	switch {
+	case manifestFile != "" && len(args) > 0:
+		return nil, errors.New("give either repositories or a manifest, not both")
+	case manifestFile != "":
+		if branch != "" || name != "" {
+			return nil, errors.New("--branch and --name cannot be used with a manifest, set them in it")
+		}
+		var err error
+		if manifest, err = config.ReadManifest(manifestFile); err != nil {
+			return nil, err
+		}
+	case len(args) == 0:
+		return nil, errors.New("give the repositories to clone or a manifest listing them")
+	case len(args) > 1 && name != "":
+		return nil, errors.New("--name can only be used when cloning a single repository")
+	default:
+		for _, url := range args {
+			repo := config.Repository{URL: url, Name: name, Branch: branch}
+			repo.SetDefaults()
+			manifest.Repositories = append(manifest.Repositories, repo)
+		}
+	}
+
+	if projectRef != "" {
+		manifest.Project = projectRef
+	}
+	if manifest.Project == "" {
+		if len(manifest.Repositories) > 1 {
+			return nil, errors.New("give the project of the repositories with --project")
+		}
+		manifest.Project = manifest.Repositories[0].Name
+	}
+
+	for i := range manifest.Repositories {
+		repo := &manifest.Repositories[i]
+		if !filepath.IsLocal(repo.Path) {
+			return nil, fmt.Errorf("the path '%s' of repository '%s' has to be inside the project directory", repo.Path, repo.Name)
+		}
+		// local repositories are recorded with an absolute path so that the
+		// manifest does not depend on the directory devkit was run from. The
+		// relative paths of a manifest are relative to the manifest.
+		if manifestFile != "" && !filepath.IsAbs(repo.URL) && !strings.Contains(repo.URL, ":") {
+			repo.URL = filepath.Join(filepath.Dir(manifestFile), repo.URL)
+		}
+		if info, err := os.Stat(repo.URL); err == nil && info.IsDir() {
+			if repo.URL, err = filepath.Abs(repo.URL); err != nil {
+				return nil, fmt.Errorf("error resolving path: %v", err)
+			}
+		}
+	}
+	return manifest, nil
+}
+
+// cloneRepository clones repo below dir and returns its environment, nil when
+// it is already registered in the project
+func cloneRepository(cmd *cobra.Command, project *config.ProjectConfig, dir string, repo config.Repository) (*config.EnvironmentConfig, error) {
+	path := filepath.Join(dir, filepath.FromSlash(repo.Path))
+	if project != nil {
+		if env, err := project.FindEnvironment(repo.Name); err == nil {
+			if filepath.Clean(env.Path) == path {
+				logrus.Infof("Environment '%s' of project '%s' is already cloned in %s", env.Name, project.Name, path)
+				return nil, nil
+			}
+			return nil, fmt.Errorf("project '%s' already has an environment named '%s' in %s, set another name", project.Name, repo.Name, env.Path)
+		}
+	}
+
+	entries, err := os.ReadDir(path)
+	switch {
+	case err != nil && !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("error reading %s: %v", path, err)
+	case len(entries) == 0:
+		logrus.Infof("Cloning %s into %s", repo.URL, path)
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			return nil, fmt.Errorf("error creating %s: %v", filepath.Dir(path), err)
+		}
+		if err := git.Clone(cmd.Context(), repo.URL, repo.Branch, path, cmd.ErrOrStderr()); err != nil {
+			return nil, err
+		}
+	default:
+		// a clone left by a previous run is reused when it is the same repository
+		url, err := git.RemoteURL(cmd.Context(), path)
+		if err != nil || url != repo.URL {
+			return nil, fmt.Errorf("%s already exists and is not a clone of %s", path, repo.URL)
+		}
+		logrus.Infof("Using the existing clone of %s in %s", repo.URL, path)
+	}
+
+	env := &config.EnvironmentConfig{
+		Name:       repo.Name,
+		Path:       path,
+		Repository: repo.URL,
+		Branch:     repo.Branch,
+	}
+	if detected, ok := detect.Detect(path); ok {
+		logrus.Infof("Detected %s in %s", detected.Language, path)
+		env.Language, env.Build, env.Test = detected.Language, detected.Build, detected.Test
+		env.Command, env.Args, env.Shell = utils.ParseCommandLine(detected.Run, strings.ContainsAny(detected.Run, "|&;<>$`"))
+	}
+	return env, nil
+}
+
+// writeManifest saves the manifest of the project in its directory
+func writeManifest(project *config.ProjectConfig, dir string) error {
+	data, err := yaml.Marshal(project.Manifest(dir))
+	if err != nil {
+		return fmt.Errorf("error marshalling manifest: %v", err)
+	}
+	if err := config.WriteFileAtomic(filepath.Join(dir, config.ManifestFileName), data, 0644); err != nil {
+		return fmt.Errorf("error writing manifest: %v", err)
+	}
+	return nil
+}
+
+// PrintManifest prints the manifest of the project given as argument
+func PrintManifest(cmd *cobra.Command, args []string) error {
+	format, err := utils.OutputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	cfg := config.GetConfig()
+	project, err := cfg.FindProject(args[0])
+	if err != nil {
+		return err
+	}
+	dir, err := cfg.WorkspacePath(project.Name)
+	if err != nil {
+		return err
+	}
+	manifest := project.Manifest(dir)
+	if len(manifest.Repositories) == 0 {
+		return fmt.Errorf("no environment of project '%s' was cloned from a repository", project.Name)
+	}
+	if format == utils.OutputJSON {
+		return utils.PrintJSON(cmd.OutOrStdout(), manifest)
+	}
+	data, err := yaml.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("error marshalling manifest: %v", err)
+	}
+	_, err = cmd.OutOrStdout().Write(data)
+	return err
+}
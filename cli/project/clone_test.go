@@ -0,0 +1,137 @@
+package project
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/leodahal4/dev-kit/cli/git"
+	"github.com/leodahal4/dev-kit/config"
+	"github.com/mitchellh/go-homedir"
+)
+
+// setupClone points the configuration and the workspace to a temporary home
+// and returns a bare repository holding a go module
+func setupClone(t *testing.T) (home, bare string) {
+	t.Helper()
+	if err := git.Available(); err != nil {
+		t.Skip(err)
+	}
+
+	home = t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("DEVKIT_WORKSPACE", filepath.Join(home, "workspace"))
+	homedir.DisableCache = true
+	if _, err := config.LoadConfig(""); err != nil {
+		t.Fatal(err)
+	}
+
+	bare = filepath.Join(t.TempDir(), "api.git")
+	src := filepath.Join(t.TempDir(), "api")
+	run := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=devkit", "-c", "user.email=devkit@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	run(home, "init", "--bare", bare)
+	run(home, "clone", bare, src)
+	if err := os.WriteFile(filepath.Join(src, "go.mod"), []byte("module example.com/api\n\ngo 1.23\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run(src, "add", "go.mod")
+	run(src, "commit", "-m", "initial commit")
+	run(src, "push", "origin", "HEAD")
+	return home, bare
+}
+
+// clone runs "devkit project clone" without its configuration checks
+func clone(t *testing.T, project string, args ...string) error {
+	t.Helper()
+	cmd := NewCloneCommand()
+	cmd.SetContext(context.Background())
+	cmd.SetErr(&strings.Builder{})
+	if project != "" {
+		if err := cmd.Flags().Set("project", project); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return Clone(cmd, args)
+}
+
+func TestClone(t *testing.T) {
+	home, bare := setupClone(t)
+
+	if err := clone(t, "shop", bare); err != nil {
+		t.Fatal(err)
+	}
+	project, err := config.GetConfig().FindProject("shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(project.Environments) != 1 {
+		t.Fatalf("got %d environments, want 1", len(project.Environments))
+	}
+	env := project.Environments[0]
+	path := filepath.Join(home, "workspace", "shop", "api")
+	if env.Name != "api" || env.Path != path || env.Repository != bare || env.Language != "go" {
+		t.Errorf("got environment %+v, want api in %s cloned from %s with go", env, path, bare)
+	}
+	if _, err := os.Stat(filepath.Join(path, "go.mod")); err != nil {
+		t.Errorf("the repository is not cloned: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(home, "workspace", "shop", config.ManifestFileName)); err != nil {
+		t.Errorf("the manifest is not written: %v", err)
+	}
+
+	// cloning again skips the registered environment
+	if err := clone(t, "shop", bare); err != nil {
+		t.Fatal(err)
+	}
+	if project, _ := config.GetConfig().FindProject("shop"); len(project.Environments) != 1 {
+		t.Errorf("got %d environments after cloning again, want 1", len(project.Environments))
+	}
+}
+
+func TestCloneProjectName(t *testing.T) {
+	home, bare := setupClone(t)
+	err := config.Update(func(cfg *config.GlobalConfig) error {
+		cfg.Projects = append(cfg.Projects,
+			config.ProjectConfig{ID: "1", Name: "shop"},
+			config.ProjectConfig{ID: "2", Name: "shop"},
+		)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"shop":     "ambiguous",
+		"7":        "taken for an ID",
+		"../shop":  "cannot contain",
+		"shop/api": "cannot contain",
+		"  ":       "cannot be empty",
+		" shop2":   "cannot start or end with spaces",
+	}
+	for project, want := range tests {
+		t.Run(project, func(t *testing.T) {
+			err := clone(t, project, bare)
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("got error %v, want one containing '%s'", err, want)
+			}
+		})
+	}
+	if n := len(config.GetConfig().Projects); n != 2 {
+		t.Errorf("got %d projects, want 2", n)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(home, "workspace")); len(entries) > 0 {
+		t.Errorf("got %d entries in the workspace, want none", len(entries))
+	}
+}
@@ -0,0 +1,27 @@
+package project
+
+import (
+	"github.com/leodahal4/dev-kit/cli/utils"
+	"github.com/spf13/cobra"
+)
+
+func NewProjectCommand() *cobra.Command {
+	projectCmd := &cobra.Command{
+		Use:   "project",
+		Short: "Manage projects",
+		Long:  "List, inspect, edit and remove the projects created with \"devkit init project\", or clone them from their repositories",
+		PreRun: func(cmd *cobra.Command, args []string) {
+			utils.ParseAndSaveCommand(cmd, args)
+		},
+	}
+
+	projectCmd.AddCommand(NewListCommand())
+	projectCmd.AddCommand(NewShowCommand())
+	projectCmd.AddCommand(NewEditCommand())
+	projectCmd.AddCommand(NewRenameCommand())
+	projectCmd.AddCommand(NewRemoveCommand())
+	projectCmd.AddCommand(NewCloneCommand())
+	projectCmd.AddCommand(NewManifestCommand())
+
+	return projectCmd
+}
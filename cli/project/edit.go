@@ -0,0 +1,110 @@
+package project
+
+import (
+	"github.com/leodahal4/dev-kit/cli/utils"
+	"github.com/leodahal4/dev-kit/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func NewEditCommand() *cobra.Command {
+	editCmd := &cobra.Command{
+		Use:   "edit <project>",
+		Short: "Edit a project",
+		Long: `Edit the description and architecture of a project. Values which are not given
+as flags are asked for in a form pre-filled with the current ones.`,
+		Example:           "  devkit project edit shop\n  devkit project edit shop --description \"Online shop\" --yes",
+		Args:              cobra.ExactArgs(1),
+		Annotations:       utils.ConfigOnly(),
+		ValidArgsFunction: utils.CompleteProjects,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			utils.ParseAndSaveCommand(cmd, args)
+		},
+		RunE: Edit,
+	}
+
+	editCmd.Flags().String("description", "", "description of the project")
+	editCmd.Flags().Bool("microservice", false, "the project follows a microservice architecture")
+	utils.AddNonInteractiveFlags(editCmd)
+
+	return editCmd
+}
+
+func NewRenameCommand() *cobra.Command {
+	renameCmd := &cobra.Command{
+		Use:               "rename <project> <new-name>",
+		Short:             "Rename a project",
+		Args:              cobra.ExactArgs(2),
+		Annotations:       utils.ConfigOnly(),
+		ValidArgsFunction: utils.CompleteProjects,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			utils.ParseAndSaveCommand(cmd, args)
+		},
+		RunE: Rename,
+	}
+
+	return renameCmd
+}
+
+// Edit updates the project referenced by the first argument
+func Edit(cmd *cobra.Command, args []string) error {
+	cfg := config.GetConfig()
+	project, err := cfg.FindProject(args[0])
+	if err != nil {
+		return err
+	}
+
+	prompt := utils.NewPrompter(cmd)
+	description := prompt.Text("description", "Description", project.Description, nil)
+	isMicroservice := prompt.Bool("microservice", "Microservice architecture", project.IsMicroservice)
+	if err := prompt.Run("Edit project " + project.Name); err != nil {
+		return err
+	}
+
+	id := project.ID
+	err = config.Update(func(cfg *config.GlobalConfig) error {
+		project, err := cfg.FindProject(id)
+		if err != nil {
+			return err
+		}
+		project.Description = description()
+		project.IsMicroservice = isMicroservice()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	logrus.Infof("Updated project '%s'", project.Name)
+	return nil
+}
+
+// Rename gives the project referenced by the first argument the name given as
+// the second one
+func Rename(cmd *cobra.Command, args []string) error {
+	cfg := config.GetConfig()
+	project, err := cfg.FindProject(args[0])
+	if err != nil {
+		return err
+	}
+	if project.Name == args[1] {
+		return nil
+	}
+
+	id, name := project.ID, project.Name
+	err = config.Update(func(cfg *config.GlobalConfig) error {
+		if err := cfg.ValidateProject(args[1]); err != nil {
+			return err
+		}
+		project, err := cfg.FindProject(id)
+		if err != nil {
+			return err
+		}
+		project.Name = args[1]
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	logrus.Infof("Renamed project '%s' to '%s'", name, args[1])
+	return nil
+}
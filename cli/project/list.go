@@ -0,0 +1,122 @@
+package project
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/leodahal4/dev-kit/cli/utils"
+	"github.com/leodahal4/dev-kit/config"
+	"github.com/spf13/cobra"
+)
+
+func NewListCommand() *cobra.Command {
+	listCmd := &cobra.Command{
+		Use:         "list",
+		Aliases:     []string{"ls"},
+		Short:       "List the projects",
+		Args:        cobra.NoArgs,
+		Annotations: utils.ConfigOnly(),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			utils.ParseAndSaveCommand(cmd, args)
+		},
+		RunE: List,
+	}
+
+	utils.AddOutputFlag(listCmd)
+
+	return listCmd
+}
+
+func NewShowCommand() *cobra.Command {
+	showCmd := &cobra.Command{
+		Use:               "show <project>",
+		Short:             "Show a project and its environments",
+		Args:              cobra.ExactArgs(1),
+		Annotations:       utils.ConfigOnly(),
+		ValidArgsFunction: utils.CompleteProjects,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			utils.ParseAndSaveCommand(cmd, args)
+		},
+		RunE: Show,
+	}
+
+	utils.AddOutputFlag(showCmd)
+
+	return showCmd
+}
+
+// List prints every project
+func List(cmd *cobra.Command, args []string) error {
+	format, err := utils.OutputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	cfg := config.GetConfig()
+	if format == utils.OutputJSON {
+		return utils.PrintJSON(cmd.OutOrStdout(), cfg.Projects)
+	}
+
+	w := utils.NewTable(cmd.OutOrStdout())
+	fmt.Fprintln(w, "ID\tNAME\tENVS\tMICROSERVICE\tDESCRIPTION")
+	for _, p := range cfg.Projects {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%t\t%s\n", p.ID, p.Name, len(p.Environments), p.IsMicroservice, utils.Cell(p.Description))
+	}
+	return w.Flush()
+}
+
+// Show prints the project referenced by the first argument
+func Show(cmd *cobra.Command, args []string) error {
+	format, err := utils.OutputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	project, err := config.GetConfig().FindProject(args[0])
+	if err != nil {
+		return err
+	}
+	if format == utils.OutputJSON {
+		return utils.PrintJSON(cmd.OutOrStdout(), project)
+	}
+
+	out := cmd.OutOrStdout()
+	w := utils.NewTable(out)
+	fmt.Fprintf(w, "ID:\t%s\n", project.ID)
+	fmt.Fprintf(w, "Name:\t%s\n", project.Name)
+	fmt.Fprintf(w, "Description:\t%s\n", utils.Cell(project.Description))
+	fmt.Fprintf(w, "Microservice:\t%t\n", project.IsMicroservice)
+	tools := utils.Cell(strings.Join(project.ToolNames(), ", "))
+	if len(project.Tools) > 0 && !project.ToolsChecked {
+		tools += " (not checked)"
+	}
+	fmt.Fprintf(w, "Tools:\t%s\n", tools)
+	fmt.Fprintf(w, "Variables:\t%d\n", len(project.Env))
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out)
+	return PrintEnvironments(out, []*config.ProjectConfig{project}, false)
+}
+
+// PrintEnvironments prints the environments of projects as a table, with a
+// column for their project when withProject is set
+func PrintEnvironments(out io.Writer, projects []*config.ProjectConfig, withProject bool) error {
+	w := utils.NewTable(out)
+	header := "ENV\tLANGUAGE\tPATH\tCOMMAND\tDEPENDS ON"
+	if withProject {
+		header = "PROJECT\t" + header
+	}
+	fmt.Fprintln(w, header)
+	for _, p := range projects {
+		for _, env := range p.Environments {
+			command := strings.TrimSpace(strings.Join(append([]string{env.Command}, env.Args...), " "))
+			row := fmt.Sprintf("%s\t%s\t%s\t%s\t%s", env.Name, utils.Cell(env.Language), utils.Cell(env.Path), utils.Cell(command), utils.Cell(strings.Join(env.DependsOn, ", ")))
+			if withProject {
+				row = p.Name + "\t" + row
+			}
+			fmt.Fprintln(w, row)
+		}
+	}
+	return w.Flush()
+}
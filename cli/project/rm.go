@@ -0,0 +1,58 @@
+package project
+
+import (
+	"fmt"
+
+	"github.com/leodahal4/dev-kit/cli/secrets"
+	"github.com/leodahal4/dev-kit/cli/utils"
+	"github.com/leodahal4/dev-kit/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func NewRemoveCommand() *cobra.Command {
+	rmCmd := &cobra.Command{
+		Use:               "rm <project>",
+		Aliases:           []string{"remove"},
+		Short:             "Remove a project and its environments",
+		Long:              "Remove a project and its environments from the configuration along with their secrets, the files of the environments are left untouched",
+		Args:              cobra.ExactArgs(1),
+		Annotations:       utils.ConfigOnly(),
+		ValidArgsFunction: utils.CompleteProjects,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			utils.ParseAndSaveCommand(cmd, args)
+		},
+		RunE: Remove,
+	}
+
+	utils.AddConfirmFlag(rmCmd)
+
+	return rmCmd
+}
+
+// Remove removes the project referenced by the first argument
+func Remove(cmd *cobra.Command, args []string) error {
+	cfg := config.GetConfig()
+	project, err := cfg.FindProject(args[0])
+	if err != nil {
+		return err
+	}
+
+	name := project.Name
+	confirmed, err := utils.Confirm(cmd, fmt.Sprintf("Remove project '%s' and its %d environments?", name, len(project.Environments)))
+	if err != nil || !confirmed {
+		return err
+	}
+	id := project.ID
+	err = config.Update(func(cfg *config.GlobalConfig) error {
+		return cfg.RemoveProject(id)
+	})
+	if err != nil {
+		return err
+	}
+	if err := secrets.Forget(id, ""); err != nil {
+		return err
+	}
+	logrus.Infof("Removed project '%s'", name)
+	return nil
+}
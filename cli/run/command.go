@@ -0,0 +1,136 @@
+package run
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/leodahal4/dev-kit/cli/secrets"
+	"github.com/leodahal4/dev-kit/config"
+)
+
+// defaultCommands maps an environment language to the command used when the
+// environment does not declare one itself.
+var defaultCommands = map[string][]string{
+	"":           {"go", "run", "main.go"},
+	"go":         {"go", "run", "."},
+	"golang":     {"go", "run", "."},
+	"node":       {"npm", "start"},
+	"nodejs":     {"npm", "start"},
+	"javascript": {"npm", "start"},
+	"typescript": {"npm", "start"},
+	"python":     {"python3", "main.py"},
+	"rust":       {"cargo", "run"},
+	"java":       {"mvn", "exec:java"},
+	"make":       {"make", "run"},
+	"makefile":   {"make", "run"},
+}
+
+// defaultCommand returns the command used for an environment of the given
+// language when it does not declare its own
+func defaultCommand(language string) ([]string, bool) {
+	command, ok := defaultCommands[strings.ToLower(strings.TrimSpace(language))]
+	return command, ok
+}
+
+// buildCommand creates the process declared by the environment, falling back
+// to the default of its language. project may be nil when the environment is
+// run on its own.
+func buildCommand(project *config.ProjectConfig, env config.EnvironmentConfig) (*exec.Cmd, error) {
+	var cmd *exec.Cmd
+	switch {
+	case env.Command != "" && env.Shell:
+		cmd = shellCommand(strings.Join(append([]string{env.Command}, env.Args...), " "))
+	case env.Command != "":
+		cmd = exec.Command(env.Command, env.Args...)
+	default:
+		command, ok := defaultCommand(env.Language)
+		if !ok {
+			return nil, fmt.Errorf("environment '%s' has no command and there is no default for language '%s'", env.Name, env.Language)
+		}
+		cmd = exec.Command(command[0], command[1:]...)
+	}
+	cmd.Dir = env.Path
+
+	environ, err := environ(project, env)
+	if err != nil {
+		return nil, fmt.Errorf("environment '%s': %v", env.Name, err)
+	}
+	cmd.Env = environ
+	return cmd, nil
+}
+
+// environ returns the variables of the process of env: the ones of devkit
+// itself overridden by the ones configured for the project and env, with the
+// secret:// references replaced by the secrets
+func environ(project *config.ProjectConfig, env config.EnvironmentConfig) ([]string, error) {
+	if project == nil {
+		project = &config.ProjectConfig{}
+	}
+
+	inherited := os.Environ()
+	vars, err := configuredVars(project, env, inherited)
+	if err != nil {
+		return nil, err
+	}
+	if err := secrets.Resolve(project.ID, env.Name, vars); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		inherited = append(inherited, k+"="+vars[k])
+	}
+	return inherited, nil
+}
+
+// configuredVars returns the variables configured for the project and env,
+// interpolated against inherited
+func configuredVars(project *config.ProjectConfig, env config.EnvironmentConfig, inherited []string) (map[string]string, error) {
+	base := make(map[string]string, len(inherited))
+	for _, kv := range inherited {
+		k, v, _ := strings.Cut(kv, "=")
+		base[k] = v
+	}
+	return project.ResolveEnv(&env, base)
+}
+
+// referencesSecrets reports whether the variables of one of the environments
+// reference a secret, which has to be unlocked before they are started
+func referencesSecrets(project *config.ProjectConfig, envs []config.EnvironmentConfig) (bool, error) {
+	if project == nil {
+		project = &config.ProjectConfig{}
+	}
+	for _, env := range envs {
+		vars, err := configuredVars(project, env, os.Environ())
+		if err != nil {
+			return false, fmt.Errorf("environment '%s': %v", env.Name, err)
+		}
+		if secrets.Referenced(vars) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func shellCommand(line string) *exec.Cmd {
+	argv := shellArgs(line)
+	return exec.Command(argv[0], argv[1:]...)
+}
+
+// shellArgs returns the argv running line through the system shell
+func shellArgs(line string) []string {
+	switch runtime.GOOS {
+	case "windows":
+		return []string{"cmd", "/C", line}
+	default:
+		return []string{"sh", "-c", line}
+	}
+}
@@ -0,0 +1,338 @@
+package run
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/leodahal4/dev-kit/cli/secrets"
+	"github.com/leodahal4/dev-kit/cli/utils"
+	"github.com/leodahal4/dev-kit/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func NewUpCommand() *cobra.Command {
+	upCmd := &cobra.Command{
+		Use:   "up <project>[/<env>]",
+		Short: "Start a project in the background",
+		Long: `Start every environment of a project, or a single environment, in the background.
+The environments are supervised exactly like "devkit run" does, and can be listed
+with "devkit ps" and stopped with "devkit down".`,
+		Example: "  devkit up shop\n  devkit up shop/api --watch",
+		Args:    cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			utils.ParseAndSaveCommand(cmd, args)
+		},
+		ValidArgsFunction: utils.CompleteTargets,
+		RunE:              Up,
+	}
+
+	upCmd.Flags().Duration("grace-period", DefaultGracePeriod, "time given to environments to exit when stopped before they are killed")
+	upCmd.Flags().BoolP("watch", "w", false, "restart an environment whenever files below its path change")
+
+	return upCmd
+}
+
+func NewDownCommand() *cobra.Command {
+	downCmd := &cobra.Command{
+		Use:     "down [<project>]",
+		Short:   "Stop a project started in the background",
+		Long:    "Stop the environments started by \"devkit up\" for a project, or for every project with --all",
+		Example: "  devkit down shop\n  devkit down --all",
+		Args:    cobra.MaximumNArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			utils.ParseAndSaveCommand(cmd, args)
+		},
+		ValidArgsFunction: utils.CompleteProjects,
+		RunE:              Down,
+	}
+
+	downCmd.Flags().Bool("all", false, "stop every project started in the background")
+	downCmd.Flags().Duration("timeout", DefaultGracePeriod+5*time.Second, "time to wait for a project to stop before it is killed")
+
+	return downCmd
+}
+
+func NewPsCommand() *cobra.Command {
+	psCmd := &cobra.Command{
+		Use:   "ps [<project>]",
+		Short: "List environments running in the background",
+		Long:  "List the environments started by \"devkit up\" with their status and uptime",
+		Args:  cobra.MaximumNArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			utils.ParseAndSaveCommand(cmd, args)
+		},
+		ValidArgsFunction: utils.CompleteProjects,
+		RunE:              Ps,
+	}
+
+	return psCmd
+}
+
+// Up starts a detached supervisor running the project or environment referenced
+// by the first argument
+func Up(cmd *cobra.Command, args []string) error {
+	cfg := config.GetConfig()
+
+	var project *config.ProjectConfig
+	var envs []config.EnvironmentConfig
+	target := ""
+	runArgs := []string{"run"}
+	if strings.Contains(args[0], "/") {
+		p, env, err := cfg.FindEnvironment(args[0])
+		if err != nil {
+			return err
+		}
+		project, target, envs = p, env.Name, []config.EnvironmentConfig{*env}
+		runArgs = append(runArgs, "env", p.ID+"/"+env.Name)
+	} else {
+		p, err := cfg.FindProject(args[0])
+		if err != nil {
+			return err
+		}
+		project, envs = p, p.Environments
+		runArgs = append(runArgs, "project", p.ID)
+	}
+	if err := project.Validate(); err != nil {
+		return err
+	}
+
+	dir, err := stateDir(project.ID)
+	if err != nil {
+		return err
+	}
+	supervisors, _, err := readStates(dir)
+	if err != nil {
+		return err
+	}
+	for _, s := range supervisors {
+		if !processAlive(s.PID) {
+			_ = os.Remove(s.path)
+			continue
+		}
+		if s.Target == target || s.Target == "" || target == "" {
+			return fmt.Errorf("%s is already running in the background (pid %d), stop it first with \"devkit down %s\"", describeTarget(s.Project, s.Target), s.PID, project.Name)
+		}
+	}
+
+	logFile := filepath.Join(dir, supervisorFile(target)+".log")
+	log, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening log file: %v", err)
+	}
+	defer log.Close()
+
+	gracePeriod, _ := cmd.Flags().GetDuration("grace-period")
+	watch, _ := cmd.Flags().GetBool("watch")
+	runArgs = append(runArgs, "--daemon", "--grace-period", gracePeriod.String())
+	if watch {
+		runArgs = append(runArgs, "--watch")
+	}
+	if cfgPath, _ := cmd.Flags().GetString("config"); cfgPath != "" {
+		runArgs = append(runArgs, "--config", cfgPath)
+	}
+
+	// the supervisor cannot ask for the passphrase of the secrets, it gets the
+	// key on its stdin instead
+	var secretsKey string
+	if needed, err := referencesSecrets(project, envs); err != nil {
+		return err
+	} else if needed {
+		store, err := secrets.Unlocked()
+		if err != nil {
+			return err
+		}
+		secretsKey = store.Key()
+		runArgs = append(runArgs, "--secrets-key-stdin")
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("error locating devkit: %v", err)
+	}
+	supervisor := exec.Command(exe, runArgs...)
+	if secretsKey != "" {
+		// written right away, the key fits in the buffer of the pipe and
+		// devkit exits once the supervisor started
+		r, w, err := os.Pipe()
+		if err != nil {
+			return fmt.Errorf("error handing the secrets over: %v", err)
+		}
+		_, err = w.WriteString(secretsKey + "\n")
+		_ = w.Close()
+		if err != nil {
+			_ = r.Close()
+			return fmt.Errorf("error handing the secrets over: %v", err)
+		}
+		defer r.Close()
+		supervisor.Stdin = r
+	}
+	supervisor.Stdout = log
+	supervisor.Stderr = log
+	detach(supervisor)
+	if err := supervisor.Start(); err != nil {
+		return fmt.Errorf("error starting %s: %v", describeTarget(project.Name, target), err)
+	}
+
+	state := SupervisorState{
+		ProjectID: project.ID,
+		Project:   project.Name,
+		Target:    target,
+		PID:       supervisor.Process.Pid,
+		StartedAt: time.Now(),
+		Command:   strings.Join(supervisor.Args, " "),
+		LogFile:   logFile,
+	}
+	if err := writeState(filepath.Join(dir, supervisorFile(target)+".json"), state); err != nil {
+		return fmt.Errorf("error recording %s: %v", describeTarget(project.Name, target), err)
+	}
+	_ = supervisor.Process.Release()
+
+	logrus.Infof("Started %s in the background (pid %d)", describeTarget(project.Name, target), state.PID)
+	logrus.Infof("Logs: %s", logFile)
+	return nil
+}
+
+// Down stops the detached supervisors of the project referenced by the first
+// argument, or of every project with --all
+func Down(cmd *cobra.Command, args []string) error {
+	all, _ := cmd.Flags().GetBool("all")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	if all == (len(args) == 1) {
+		return errors.New("give either a project or --all")
+	}
+
+	var supervisors []SupervisorState
+	var envs []EnvState
+	var err error
+	if all {
+		supervisors, envs, err = readAllStates()
+	} else {
+		var project *config.ProjectConfig
+		if project, err = config.GetConfig().FindProject(args[0]); err != nil {
+			return err
+		}
+		var dir string
+		if dir, err = stateDir(project.ID); err != nil {
+			return err
+		}
+		supervisors, envs, err = readStates(dir)
+	}
+	if err != nil {
+		return err
+	}
+	if len(supervisors) == 0 && len(envs) == 0 {
+		logrus.Info("Nothing is running in the background")
+		return nil
+	}
+
+	for _, s := range supervisors {
+		stopSupervisor(s, envs, timeout)
+		_ = os.Remove(s.path)
+	}
+
+	// whatever is left was not removed by its supervisor
+	for _, e := range envs {
+		if _, err := os.Stat(e.path); err != nil {
+			continue
+		}
+		if e.Status == StatusRunning && processAlive(e.PID) && !processAlive(e.SupervisorPID) {
+			logrus.Warnf("%s (pid %d) was left behind by a supervisor which died, it may still be running", describeTarget(e.Project, e.Env), e.PID)
+		}
+		_ = os.Remove(e.path)
+	}
+	return nil
+}
+
+// stopSupervisor asks a supervisor to stop its environments, and kills it and
+// its environments when it did not exit within timeout
+func stopSupervisor(s SupervisorState, envs []EnvState, timeout time.Duration) {
+	name := describeTarget(s.Project, s.Target)
+	if !processAlive(s.PID) {
+		logrus.Infof("%s is not running anymore, removing its stale record", name)
+		return
+	}
+
+	logrus.Infof("Stopping %s (pid %d)", name, s.PID)
+	if err := terminateProcess(s.PID); err != nil {
+		logrus.Debugf("signalling %s: %v", name, err)
+	}
+	deadline := time.Now().Add(timeout)
+	for processAlive(s.PID) && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if !processAlive(s.PID) {
+		logrus.Infof("Stopped %s", name)
+		return
+	}
+
+	logrus.Warnf("%s did not stop within %s, killing it", name, timeout)
+	for _, e := range envs {
+		if e.SupervisorPID == s.PID && e.Status == StatusRunning && processAlive(e.PID) {
+			_ = killGroup(e.PID)
+		}
+	}
+	_ = killProcess(s.PID)
+}
+
+// Ps lists the environments started in the background
+func Ps(cmd *cobra.Command, args []string) error {
+	var envs []EnvState
+	var err error
+	if len(args) == 1 {
+		project, err := config.GetConfig().FindProject(args[0])
+		if err != nil {
+			return err
+		}
+		dir, err := stateDir(project.ID)
+		if err != nil {
+			return err
+		}
+		_, envs, err = readStates(dir)
+		if err != nil {
+			return err
+		}
+	} else if _, envs, err = readAllStates(); err != nil {
+		return err
+	}
+
+	if len(envs) == 0 {
+		logrus.Info("Nothing is running in the background")
+		return nil
+	}
+	sort.Slice(envs, func(i, j int) bool {
+		if envs[i].Project != envs[j].Project {
+			return envs[i].Project < envs[j].Project
+		}
+		return envs[i].Env < envs[j].Env
+	})
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROJECT\tENV\tSTATUS\tPID\tUPTIME\tCOMMAND\tLOG")
+	for _, e := range envs {
+		status := e.liveStatus()
+		pid, uptime := "-", "-"
+		if e.PID > 0 {
+			pid = fmt.Sprint(e.PID)
+		}
+		if status == StatusRunning {
+			uptime = time.Since(e.StartedAt).Round(time.Second).String()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", e.Project, e.Env, status, pid, uptime, e.Command, e.LogFile)
+	}
+	return w.Flush()
+}
+
+func describeTarget(project, env string) string {
+	if env == "" {
+		return project
+	}
+	return project + "/" + env
+}
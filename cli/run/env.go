@@ -3,56 +3,38 @@ package run
 import (
 	"github.com/leodahal4/dev-kit/cli/utils"
 	"github.com/leodahal4/dev-kit/config"
-	"github.com/sirupsen/logrus"
-
-	"os"
-	"os/exec"
 
 	"github.com/spf13/cobra"
 )
 
 func NewEnvRun() *cobra.Command {
 	runCmd := &cobra.Command{
-		Use:   "env",
-		Short: "Run environment",
-		Long:  "Run any environment",
+		Use:     "env <project>/<env>",
+		Short:   "Run environment",
+		Long:    "Run any environment, selected by its project ID or name and its own name",
+		Example: "  devkit run env shop/api\n  devkit run env 1/api",
+		Args:    cobra.ExactArgs(1),
 		PreRun: func(cmd *cobra.Command, args []string) {
 			utils.ParseAndSaveCommand(cmd, args)
 		},
-		RunE: InitEnvRun,
+		ValidArgsFunction: utils.CompleteEnvironments,
+		RunE:              InitEnvRun,
 	}
 
-	runCmd.Flags().IntP("id", "i", 0, "id to run env")
-
 	return runCmd
 }
 
-// InitProject handles the initialization of a new project
+// InitEnvRun runs the environment referenced by the first argument
 func InitEnvRun(cmd *cobra.Command, args []string) error {
 	cfg := config.GetConfig()
-	envPath := cfg.Projects[0].Environments[1].Path
-	_ = RunENV(envPath)
-	return nil
-}
-
-func RunENV(path string) error {
-	cmdToRun := exec.Command("go", "run", "main.go")
-	cmdToRun.Dir = path
-
-	// Stream output directly to stdout and stderr
-	cmdToRun.Stdout = os.Stdout // Stream standard output
-	cmdToRun.Stderr = os.Stderr // Stream standard error
-
-	err := cmdToRun.Start() // Start the command
+	project, env, err := cfg.FindEnvironment(args[0])
 	if err != nil {
-		logrus.Errorf("ERR %v", err)
 		return err
 	}
+	return runEnvironments(cmd, project, []config.EnvironmentConfig{*env})
+}
 
-	err = cmdToRun.Wait() // Wait for the command to finish
-	if err != nil {
-		logrus.Errorf("ERR %v", err)
-		return err
-	}
-	return nil
+// RunENV runs the command declared by the environment and waits for it to exit
+func RunENV(env config.EnvironmentConfig) error {
+	return NewSupervisor(DefaultGracePeriod).Add(env).Run()
 }
@@ -0,0 +1,254 @@
+package run
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/leodahal4/dev-kit/cli/secrets"
+	"github.com/leodahal4/dev-kit/cli/utils"
+	"github.com/leodahal4/dev-kit/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func NewExecCommand() *cobra.Command {
+	execCmd := &cobra.Command{
+		Use:   "exec <project>[/<env>] -- <command> [args...]",
+		Short: "Run a command in the path of every environment of a project",
+		Long: `Run a command in the path of every environment of a project, with the variables
+of the environment, one environment after the other or several at once with
+--parallel. The output is prefixed with the name of the environment, and a
+summary of the exit codes and durations is printed at the end.`,
+		Example: "  devkit exec shop -- go mod tidy\n" +
+			"  devkit exec shop --language node --parallel -- npm ci\n" +
+			"  devkit exec shop --name 'svc-*' --shell -- 'make lint | tee lint.log'",
+		Args:              cobra.MinimumNArgs(2),
+		ValidArgsFunction: utils.CompleteTargets,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			utils.ParseAndSaveCommand(cmd, args)
+		},
+		RunE: Exec,
+	}
+
+	execCmd.Flags().StringSliceP("language", "l", nil, "only run in the environments of these languages")
+	execCmd.Flags().StringSliceP("name", "n", nil, "only run in the environments whose name matches one of these globs")
+	execCmd.Flags().BoolP("parallel", "p", false, "run in several environments at once")
+	execCmd.Flags().IntP("jobs", "j", runtime.NumCPU(), "number of environments run at once with --parallel")
+	execCmd.Flags().Bool("fail-fast", false, "do not start the command in more environments once it failed")
+	execCmd.Flags().Bool("shell", false, "run the command through the system shell")
+
+	return execCmd
+}
+
+// execResult is the outcome of the command in an environment
+type execResult struct {
+	Env      string
+	Started  bool
+	ExitCode int
+	Err      error
+	Duration time.Duration
+}
+
+// Exec runs the command following "--" in the environments referenced by the
+// first argument
+func Exec(cmd *cobra.Command, args []string) error {
+	argv := args[1:]
+	// without "--" the flags of the command would be taken for the ones of
+	// exec, e.g. -j of "make -j 4"
+	if cmd.ArgsLenAtDash() != 1 {
+		return errors.New("give a single project or environment, then the command after '--'")
+	}
+	if shell, _ := cmd.Flags().GetBool("shell"); shell {
+		argv = shellArgs(strings.Join(argv, " "))
+	}
+	parallel, _ := cmd.Flags().GetBool("parallel")
+	jobs, _ := cmd.Flags().GetInt("jobs")
+	if jobs < 1 {
+		return errors.New("--jobs has to be at least 1")
+	}
+	if !parallel {
+		jobs = 1
+	}
+	failFast, _ := cmd.Flags().GetBool("fail-fast")
+
+	project, envs, err := execTargets(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	if needed, err := referencesSecrets(project, envs); err != nil {
+		return err
+	} else if needed {
+		if _, err := secrets.Unlocked(); err != nil {
+			return err
+		}
+	}
+
+	// Ctrl-C reaches the commands, which share the terminal, devkit only
+	// stops starting new ones and still prints the summary
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	var stopOnce sync.Once
+	stopped := make(chan struct{})
+	stop := func() { stopOnce.Do(func() { close(stopped) }) }
+	go func() {
+		select {
+		case sig := <-signals:
+			logrus.Infof("received %s, not starting the command in more environments", sig)
+			stop()
+		case <-stopped:
+		}
+	}()
+	defer stop()
+
+	names := make([]string, len(envs))
+	for i, env := range envs {
+		names[i] = env.Name
+	}
+	mux := NewMux(os.Stdout, os.Stderr, names)
+
+	results := make([]execResult, len(envs))
+	for i, env := range envs {
+		results[i].Env = env.Name
+	}
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(jobs, len(envs)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				select {
+				case <-stopped:
+					continue
+				default:
+				}
+				results[i] = execIn(project, envs[i], argv, mux)
+				if results[i].Err != nil && failFast {
+					stop()
+				}
+			}
+		}()
+	}
+queueing:
+	for i := range envs {
+		select {
+		case queue <- i:
+		case <-stopped:
+			break queueing
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	return printExecSummary(cmd, results)
+}
+
+// execTargets returns the environments referenced by ref which pass the
+// --language and --name filters
+func execTargets(cmd *cobra.Command, ref string) (*config.ProjectConfig, []config.EnvironmentConfig, error) {
+	project, envs, err := config.GetConfig().FindTargets(ref)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	languages, _ := cmd.Flags().GetStringSlice("language")
+	globs, _ := cmd.Flags().GetStringSlice("name")
+	for _, glob := range globs {
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, nil, fmt.Errorf("invalid glob '%s': %v", glob, err)
+		}
+	}
+	var selected []config.EnvironmentConfig
+	for _, env := range envs {
+		if len(languages) > 0 && !slices.ContainsFunc(languages, func(l string) bool { return strings.EqualFold(l, env.Language) }) {
+			continue
+		}
+		if len(globs) > 0 && !slices.ContainsFunc(globs, func(g string) bool { ok, _ := path.Match(g, env.Name); return ok }) {
+			continue
+		}
+		selected = append(selected, env)
+	}
+	if len(selected) == 0 {
+		return nil, nil, fmt.Errorf("no environment of project '%s' matches", project.Name)
+	}
+	return project, selected, nil
+}
+
+// execIn runs argv in the path of env, writing its output through mux
+func execIn(project *config.ProjectConfig, env config.EnvironmentConfig, argv []string, mux *Mux) (result execResult) {
+	result = execResult{Env: env.Name, Started: true, ExitCode: -1}
+	start := time.Now()
+	defer func() { result.Duration = time.Since(start) }()
+
+	command := exec.Command(argv[0], argv[1:]...)
+	command.Dir = env.Path
+	var err error
+	if command.Env, err = environ(project, env); err != nil {
+		result.Err = err
+		return result
+	}
+	stdout, stderr := mux.Writers(env.Name)
+	defer stdout.Flush()
+	defer stderr.Flush()
+	command.Stdout, command.Stderr = stdout, stderr
+	command.WaitDelay = pipeWaitDelay
+
+	result.Err = command.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case result.Err == nil:
+		result.ExitCode = 0
+	case errors.As(result.Err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	}
+	return result
+}
+
+// printExecSummary prints the exit code and duration of the command in every
+// environment, and returns an error when it failed in any of them
+func printExecSummary(cmd *cobra.Command, results []execResult) error {
+	failed, skipped := 0, 0
+	w := utils.NewTable(cmd.OutOrStdout())
+	fmt.Fprintln(w, "\nENV\tEXIT\tDURATION\tERROR")
+	for _, r := range results {
+		switch {
+		case !r.Started:
+			skipped++
+			fmt.Fprintf(w, "%s\t-\t-\tnot run\n", r.Env)
+			continue
+		case r.Err != nil:
+			failed++
+		}
+		exit, message := "-", ""
+		if r.ExitCode >= 0 {
+			exit = fmt.Sprint(r.ExitCode)
+		}
+		var exitErr *exec.ExitError
+		if r.Err != nil && !errors.As(r.Err, &exitErr) {
+			message = r.Err.Error()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Env, exit, r.Duration.Round(time.Millisecond), utils.Cell(message))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	switch {
+	case failed > 0:
+		return fmt.Errorf("the command failed in %d of %d environment(s)", failed, len(results))
+	case skipped > 0:
+		return fmt.Errorf("the command was not run in %d of %d environment(s)", skipped, len(results))
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package run
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leodahal4/dev-kit/config"
+)
+
+const (
+	// maxLogSize is the size at which a log file is rotated, and logBackups
+	// the number of rotated files kept next to it
+	maxLogSize = 10 * 1024 * 1024
+	logBackups = 5
+
+	logFileName = "output.log"
+)
+
+// Streams recorded in log files
+const (
+	streamStdout = "out"
+	streamStderr = "err"
+)
+
+// envLogPath returns the log file of an environment,
+// ~/.dev-kit/logs/<project-id>/<env>/output.log
+func envLogPath(projectID, env string) (string, error) {
+	return config.DevKitPath("logs", url.PathEscape(projectID), url.PathEscape(env), logFileName)
+}
+
+// rotatingFile is a log file which is rotated once it grows past maxSize,
+// keeping the given number of older files as <path>.1 (newest) to <path>.N
+type rotatingFile struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	backups int
+	file    *os.File
+	size    int64
+}
+
+func openRotatingFile(path string, maxSize int64, backups int) (*rotatingFile, error) {
+	r := &rotatingFile{path: path, maxSize: maxSize, backups: backups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening log file: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("error opening log file: %v", err)
+	}
+	r.file, r.size = file, info.Size()
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	for i := r.backups - 1; i >= 1; i-- {
+		err := os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	if err := os.Rename(r.path, r.path+".1"); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// envLog records the output of an environment, one timestamped line at a time
+type envLog struct {
+	file *rotatingFile
+}
+
+func openEnvLog(projectID, env string) (*envLog, error) {
+	path, err := envLogPath(projectID, env)
+	if err != nil {
+		return nil, err
+	}
+	file, err := openRotatingFile(path, maxLogSize, logBackups)
+	if err != nil {
+		return nil, err
+	}
+	return &envLog{file: file}, nil
+}
+
+// Writers returns the stdout and stderr writers of the log, they must be
+// flushed once the process has exited
+func (l *envLog) Writers() (*LineWriter, *LineWriter) {
+	return &LineWriter{emit: func(line []byte) { l.writeLine(streamStdout, line) }},
+		&LineWriter{emit: func(line []byte) { l.writeLine(streamStderr, line) }}
+}
+
+func (l *envLog) writeLine(stream string, line []byte) {
+	_, _ = fmt.Fprintf(l.file, "%s %s %s\n", time.Now().UTC().Format(time.RFC3339Nano), stream, line)
+}
+
+func (l *envLog) Close() error {
+	return l.file.Close()
+}
+
+// logEntry is a line read back from a log file
+type logEntry struct {
+	Time   time.Time
+	Env    string
+	Stream string
+	Text   string
+}
+
+// parseLogLine parses a line written by envLog.writeLine
+func parseLogLine(env, line string) (logEntry, bool) {
+	ts, rest, ok := strings.Cut(line, " ")
+	if !ok {
+		return logEntry{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return logEntry{}, false
+	}
+	stream, text, _ := strings.Cut(rest, " ")
+	return logEntry{Time: t, Env: env, Stream: stream, Text: text}, true
+}
+
+// readLogFile reads every entry of a log file accepted by keep
+func readLogFile(path, env string, keep func(logEntry) bool) ([]logEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []logEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineLength+1024)
+	for scanner.Scan() {
+		entry, ok := parseLogLine(env, scanner.Text())
+		if ok && keep(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,275 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/leodahal4/dev-kit/cli/utils"
+	"github.com/leodahal4/dev-kit/config"
+	"github.com/spf13/cobra"
+)
+
+// followInterval is how often log files are polled by "devkit logs --follow"
+const followInterval = 250 * time.Millisecond
+
+func NewLogsCommand() *cobra.Command {
+	logsCmd := &cobra.Command{
+		Use:   "logs <project>[/<env>]",
+		Short: "Show the logs of a project or environment",
+		Long: `Show the output recorded by "devkit run" and "devkit up" for a project or a single
+environment. The logs of several environments are merged by timestamp.`,
+		Example: "  devkit logs shop\n  devkit logs shop/api --follow\n  devkit logs shop --since 10m --grep error --tail 50",
+		Args:    cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			utils.ParseAndSaveCommand(cmd, args)
+		},
+		ValidArgsFunction: utils.CompleteTargets,
+		RunE:              Logs,
+	}
+
+	logsCmd.Flags().BoolP("follow", "f", false, "keep printing new lines as they are logged")
+	logsCmd.Flags().String("since", "", "only show lines newer than a duration (e.g. 10m) or an RFC 3339 time")
+	logsCmd.Flags().String("grep", "", "only show lines matching this regular expression")
+	logsCmd.Flags().IntP("tail", "n", -1, "only show the last N lines")
+	logsCmd.Flags().BoolP("timestamps", "t", false, "show the time of every line")
+
+	return logsCmd
+}
+
+// Logs prints the logs of the project or environment referenced by the first
+// argument
+func Logs(cmd *cobra.Command, args []string) error {
+	follow, _ := cmd.Flags().GetBool("follow")
+	since, _ := cmd.Flags().GetString("since")
+	grep, _ := cmd.Flags().GetString("grep")
+	tail, _ := cmd.Flags().GetInt("tail")
+	timestamps, _ := cmd.Flags().GetBool("timestamps")
+
+	project, targets, err := config.GetConfig().FindTargets(args[0])
+	if err != nil {
+		return err
+	}
+	var envs []string
+	for _, env := range targets {
+		if !slices.Contains(envs, env.Name) {
+			envs = append(envs, env.Name)
+		}
+	}
+
+	keep, err := logFilter(since, grep)
+	if err != nil {
+		return err
+	}
+
+	var entries []logEntry
+	tailers := make([]*logTailer, len(envs))
+	for i, env := range envs {
+		path, err := envLogPath(project.ID, env)
+		if err != nil {
+			return err
+		}
+		for n := logBackups; n >= 1; n-- {
+			rotated, err := readLogFile(fmt.Sprintf("%s.%d", path, n), env, keep)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, rotated...)
+		}
+
+		tailers[i] = &logTailer{path: path, env: env}
+		current, err := tailers[i].read(keep)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, current...)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	if tail >= 0 && len(entries) > tail {
+		entries = entries[len(entries)-tail:]
+	}
+
+	printer := newLogPrinter(cmd.OutOrStdout(), envs, timestamps)
+	for _, entry := range entries {
+		printer.print(entry)
+	}
+	if !follow {
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ticker := time.NewTicker(followInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		var batch []logEntry
+		for _, t := range tailers {
+			entries, err := t.read(keep)
+			if err != nil {
+				return err
+			}
+			batch = append(batch, entries...)
+		}
+		sort.SliceStable(batch, func(i, j int) bool { return batch[i].Time.Before(batch[j].Time) })
+		for _, entry := range batch {
+			printer.print(entry)
+		}
+	}
+}
+
+// logFilter returns the filter of the --since and --grep flags
+func logFilter(since, grep string) (func(logEntry) bool, error) {
+	var after time.Time
+	if since != "" {
+		if d, err := time.ParseDuration(since); err == nil {
+			after = time.Now().Add(-d)
+		} else if t, err := time.Parse(time.RFC3339, since); err == nil {
+			after = t
+		} else {
+			return nil, fmt.Errorf("invalid --since '%s', use a duration such as 10m or an RFC 3339 time", since)
+		}
+	}
+
+	var pattern *regexp.Regexp
+	if grep != "" {
+		var err error
+		if pattern, err = regexp.Compile(grep); err != nil {
+			return nil, fmt.Errorf("invalid --grep pattern: %v", err)
+		}
+	}
+
+	return func(e logEntry) bool {
+		if !after.IsZero() && e.Time.Before(after) {
+			return false
+		}
+		return pattern == nil || pattern.MatchString(e.Text)
+	}, nil
+}
+
+// logTailer reads the lines appended to a log file since its last read,
+// following it across rotations
+type logTailer struct {
+	path   string
+	env    string
+	offset int64
+	info   os.FileInfo
+}
+
+func (t *logTailer) read(keep func(logEntry) bool) ([]logEntry, error) {
+	info, err := os.Stat(t.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []logEntry
+	if t.info != nil && !os.SameFile(t.info, info) {
+		// the file was rotated, finish reading it under its new name first
+		if rotated, err := os.Stat(t.path + ".1"); err == nil && os.SameFile(t.info, rotated) {
+			if entries, _, err = readLogFrom(t.path+".1", t.env, t.offset, keep); err != nil {
+				return nil, err
+			}
+		}
+		t.offset = 0
+	} else if info.Size() < t.offset {
+		t.offset = 0
+	}
+	t.info = info
+
+	current, offset, err := readLogFrom(t.path, t.env, t.offset, keep)
+	if err != nil {
+		return nil, err
+	}
+	t.offset = offset
+	return append(entries, current...), nil
+}
+
+// readLogFrom reads the complete lines of a log file starting at offset, and
+// returns the offset following the last of them
+func readLogFrom(path, env string, offset int64, keep func(logEntry) bool) ([]logEntry, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, offset, err
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, err
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, offset, err
+	}
+	end := bytes.LastIndexByte(data, '\n')
+	if end < 0 {
+		return nil, offset, nil
+	}
+
+	var entries []logEntry
+	for _, line := range strings.Split(string(data[:end]), "\n") {
+		entry, ok := parseLogLine(env, line)
+		if ok && keep(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, offset + int64(end) + 1, nil
+}
+
+// logPrinter prints log entries, prefixed with their environment when the logs
+// of several environments are merged
+type logPrinter struct {
+	out        io.Writer
+	mux        *Mux
+	writers    map[string]*LineWriter
+	timestamps bool
+}
+
+func newLogPrinter(out io.Writer, envs []string, timestamps bool) *logPrinter {
+	p := &logPrinter{out: out, timestamps: timestamps}
+	if len(envs) > 1 {
+		p.mux = NewMux(out, out, envs)
+		p.writers = map[string]*LineWriter{}
+	}
+	return p
+}
+
+func (p *logPrinter) print(e logEntry) {
+	line := e.Text
+	if p.timestamps {
+		line = e.Time.Local().Format("2006-01-02 15:04:05.000") + " " + line
+	}
+	if p.mux == nil {
+		fmt.Fprintln(p.out, line)
+		return
+	}
+
+	key := e.Env + "\x00" + e.Stream
+	w, ok := p.writers[key]
+	if !ok {
+		stdout, stderr := p.mux.Writers(e.Env)
+		w = stdout
+		if e.Stream == streamStderr {
+			w = stderr
+		}
+		p.writers[key] = w
+	}
+	_, _ = w.Write([]byte(line + "\n"))
+}
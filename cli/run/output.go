@@ -0,0 +1,175 @@
+package run
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sirupsen/logrus"
+)
+
+// maxLineLength is the size at which a line without a newline is flushed anyway
+const maxLineLength = 64 * 1024
+
+// prefixColors are the colors assigned to environment names, chosen to stay
+// readable on both dark and light terminals
+var prefixColors = []lipgloss.Color{"39", "208", "141", "42", "205", "220", "81", "168", "114", "177"}
+
+var stderrMarkStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Bold(true)
+
+// Mux multiplexes the output of several environments. Every line is prefixed
+// with the name of the environment it came from, whole lines are written at
+// once so concurrent environments never interleave mid-line, and stderr lines
+// are marked with a red "!" instead of "|".
+type Mux struct {
+	mu     sync.Mutex
+	stdout io.Writer
+	stderr io.Writer
+	width  int
+	names  []string
+	only   map[string]bool
+}
+
+func NewMux(stdout, stderr io.Writer, names []string) *Mux {
+	m := &Mux{stdout: stdout, stderr: stderr, names: names}
+	for _, name := range names {
+		m.width = max(m.width, lipgloss.Width(name))
+	}
+	return m
+}
+
+// SetFilter limits the output to the given environments, an empty list shows
+// every environment again. It is safe to call while environments are running.
+func (m *Mux) SetFilter(names []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.only = nil
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			if m.only == nil {
+				m.only = map[string]bool{}
+			}
+			m.only[name] = true
+		}
+	}
+}
+
+// Writers returns the stdout and stderr writers of an environment. They must be
+// flushed once the process has exited to emit a trailing partial line.
+func (m *Mux) Writers(name string) (*LineWriter, *LineWriter) {
+	color := prefixColors[colorIndex(name)]
+	prefix := lipgloss.NewStyle().Foreground(color).Bold(true).Render(fmt.Sprintf("%-*s", m.width, name))
+
+	stdout := &LineWriter{emit: func(line []byte) {
+		m.writeLine(name, m.stdout, prefix+" | ", line)
+	}}
+	stderr := &LineWriter{emit: func(line []byte) {
+		m.writeLine(name, m.stderr, prefix+" "+stderrMarkStyle.Render("!")+" ", line)
+	}}
+	return stdout, stderr
+}
+
+func (m *Mux) writeLine(name string, out io.Writer, prefix string, line []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.only != nil && !m.only[name] {
+		return
+	}
+	_, _ = io.WriteString(out, prefix)
+	_, _ = out.Write(line)
+	_, _ = io.WriteString(out, "\n")
+}
+
+// ReadFilterCommands reads filter commands typed while environments are
+// running: ":only api,worker" shows only the listed environments and ":all"
+// shows every environment again. A filter naming unknown environments is
+// reported and leaves the current one in place.
+func (m *Mux) ReadFilterCommands(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		command, arg, _ := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		switch command {
+		case ":only":
+			names := strings.Split(arg, ",")
+			if err := m.checkNames(names); err != nil {
+				logrus.Error(err)
+				continue
+			}
+			m.SetFilter(names)
+		case ":all":
+			m.SetFilter(nil)
+		}
+	}
+}
+
+// checkNames fails when names lists no environment, or environments which are
+// not multiplexed
+func (m *Mux) checkNames(names []string) error {
+	given := false
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if !slices.Contains(m.names, name) {
+			return fmt.Errorf("environment '%s' is not part of this run, use one of %s", name, strings.Join(m.names, ", "))
+		}
+		given = true
+	}
+	if !given {
+		return errors.New("give the environments to show, e.g. ':only " + m.names[0] + "'")
+	}
+	return nil
+}
+
+func colorIndex(name string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	return int(h.Sum32() % uint32(len(prefixColors)))
+}
+
+// LineWriter buffers writes and emits them one complete line at a time
+type LineWriter struct {
+	mu   sync.Mutex
+	buf  []byte
+	emit func(line []byte)
+}
+
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.emit(bytes.TrimSuffix(w.buf[:i], []byte("\r")))
+		w.buf = w.buf[i+1:]
+	}
+	if len(w.buf) >= maxLineLength {
+		w.emit(w.buf)
+		w.buf = nil
+	}
+	return len(p), nil
+}
+
+// Flush emits the buffered partial line, if any
+func (w *LineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) > 0 {
+		w.emit(w.buf)
+		w.buf = nil
+	}
+}
@@ -0,0 +1,90 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"time"
+
+	"github.com/leodahal4/dev-kit/config"
+)
+
+// probeAttemptTimeout bounds a single TCP or HTTP probe attempt
+const probeAttemptTimeout = 5 * time.Second
+
+// waitReady retries the readiness probe of env until it passes, its timeout
+// expires or ctx is cancelled
+func waitReady(ctx context.Context, env config.EnvironmentConfig) error {
+	if !env.Ready.IsSet() {
+		return nil
+	}
+	timeout, interval, err := env.Ready.Durations()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err := probeOnce(ctx, env)
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return fmt.Errorf("not ready after %s: %v", timeout, err)
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+// probeOnce runs every check of the readiness probe of env once
+func probeOnce(ctx context.Context, env config.EnvironmentConfig) error {
+	probe := env.Ready
+
+	if probe.TCP != "" {
+		dialer := net.Dialer{Timeout: probeAttemptTimeout}
+		conn, err := dialer.DialContext(ctx, "tcp", probe.TCP)
+		if err != nil {
+			return err
+		}
+		_ = conn.Close()
+	}
+
+	if probe.HTTP != "" {
+		attemptCtx, cancel := context.WithTimeout(ctx, probeAttemptTimeout)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(attemptCtx, http.MethodGet, probe.HTTP, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("%s answered %s", probe.HTTP, resp.Status)
+		}
+	}
+
+	if probe.Command != "" {
+		argv := shellArgs(probe.Command)
+		cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+		cmd.Dir = env.Path
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("'%s': %v", probe.Command, err)
+		}
+	}
+	return nil
+}
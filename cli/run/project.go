@@ -1,8 +1,7 @@
 package run
 
 import (
-	"sync"
-
+	"fmt"
 	"github.com/leodahal4/dev-kit/cli/utils"
 	"github.com/leodahal4/dev-kit/config"
 
@@ -11,34 +10,33 @@ import (
 
 func NewProjectRun() *cobra.Command {
 	runCmd := &cobra.Command{
-		Use:   "project",
-		Short: "Run environment",
-		Long:  "Run any environment",
+		Use:     "project <id-or-name>",
+		Short:   "Run project",
+		Long:    "Run every environment of a project, selected by its ID or name",
+		Example: "  devkit run project shop\n  devkit run project 1",
+		Args:    cobra.ExactArgs(1),
 		PreRun: func(cmd *cobra.Command, args []string) {
 			utils.ParseAndSaveCommand(cmd, args)
 		},
-		RunE: InitProjectRun,
+		ValidArgsFunction: utils.CompleteProjects,
+		RunE:              InitProjectRun,
 	}
 
-	runCmd.Flags().IntP("id", "i", 0, "id to run env")
+	runCmd.Flags().StringSlice("only", nil, "only show the output of these environments")
 
 	return runCmd
 }
 
-// InitProject handles the initialization of a new project
+// InitProjectRun runs every environment of the project referenced by the first argument
 func InitProjectRun(cmd *cobra.Command, args []string) error {
 	cfg := config.GetConfig()
-	envs := cfg.Projects[0].Environments
-	var wg sync.WaitGroup
-	for _, env := range envs {
-		wg.Add(1)
-		go func() {
-			err := RunENV(env.Path)
-			if err != nil {
-				wg.Done()
-			}
-		}()
+	project, err := cfg.FindProject(args[0])
+	if err != nil {
+		return err
+	}
+	envs, err := project.SortEnvironments()
+	if err != nil {
+		return fmt.Errorf("project '%s': %v", project.Name, err)
 	}
-	wg.Wait()
-	return nil
+	return runEnvironments(cmd, project, envs)
 }
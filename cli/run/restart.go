@@ -0,0 +1,93 @@
+package run
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/leodahal4/dev-kit/config"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// initialBackoff is the delay before the first restart, doubled after
+	// every consecutive restart up to maxBackoff
+	initialBackoff = time.Second
+	maxBackoff     = 30 * time.Second
+
+	// stableUptime is how long a process has to run before its backoff is
+	// reset and it no longer counts towards the crash loop detection
+	stableUptime = 10 * time.Second
+
+	// crashLoopLimit short lived runs within crashLoopWindow are treated as a
+	// crash loop and the environment is not restarted anymore
+	crashLoopLimit  = 5
+	crashLoopWindow = time.Minute
+)
+
+// restarter decides whether an exited environment is started again
+type restarter struct {
+	env      config.EnvironmentConfig
+	restarts int
+	backoff  time.Duration
+	crashes  []time.Time
+}
+
+func newRestarter(env config.EnvironmentConfig) *restarter {
+	return &restarter{env: env, backoff: initialBackoff}
+}
+
+// next returns the delay before restarting a process which ran for uptime and
+// exited with err. It returns false with the final error when the process must
+// not be restarted.
+func (r *restarter) next(err error, uptime time.Duration) (time.Duration, bool, error) {
+	switch r.env.Restart {
+	case config.RestartAlways:
+	case config.RestartOnFailure:
+		if err == nil {
+			return 0, false, nil
+		}
+	default:
+		return 0, false, err
+	}
+
+	if r.env.MaxRestarts > 0 && r.restarts >= r.env.MaxRestarts {
+		logrus.Warnf("%s reached the limit of %d restarts", r.env.Name, r.env.MaxRestarts)
+		if err != nil {
+			return 0, false, fmt.Errorf("gave up after %d restarts: %w", r.restarts, err)
+		}
+		return 0, false, nil
+	}
+
+	now := time.Now()
+	if uptime >= stableUptime {
+		r.backoff = initialBackoff
+		r.crashes = nil
+	} else {
+		r.crashes = append(r.crashes, now)
+		for len(r.crashes) > 0 && now.Sub(r.crashes[0]) > crashLoopWindow {
+			r.crashes = r.crashes[1:]
+		}
+		if len(r.crashes) >= crashLoopLimit {
+			logrus.Errorf("%s is crash looping, it exited %d times within %s", r.env.Name, len(r.crashes), crashLoopWindow)
+			if err == nil {
+				err = fmt.Errorf("exited %d times within %s", len(r.crashes), crashLoopWindow)
+			}
+			return 0, false, fmt.Errorf("crash loop detected: %w", err)
+		}
+	}
+
+	delay := r.backoff
+	r.backoff = min(r.backoff*2, maxBackoff)
+	r.restarts++
+
+	reason := "exited"
+	if err != nil {
+		reason = fmt.Sprintf("exited with %v", err)
+	}
+	limit := ""
+	if r.env.MaxRestarts > 0 {
+		limit = fmt.Sprintf("/%d", r.env.MaxRestarts)
+	}
+	logrus.Warnf("%s %s, restarting in %s (restart %d%s, policy %s)", r.env.Name, reason, delay, r.restarts, limit, r.env.Restart)
+	return delay, true, nil
+}
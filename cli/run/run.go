@@ -1,8 +1,17 @@
 package run
 
 import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/leodahal4/dev-kit/cli/secrets"
 	"github.com/leodahal4/dev-kit/cli/utils"
+	"github.com/leodahal4/dev-kit/config"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 func NewRun() *cobra.Command {
@@ -15,8 +24,99 @@ func NewRun() *cobra.Command {
 		},
 	}
 
+	runCmd.PersistentFlags().Duration("grace-period", DefaultGracePeriod, "time given to environments to exit after Ctrl-C before they are killed")
+	runCmd.PersistentFlags().BoolP("watch", "w", false, "restart an environment whenever files below its path change")
+	runCmd.PersistentFlags().Bool("no-log-files", false, "do not record the output of the environments for \"devkit logs\"")
+	// set by "devkit up" on the supervisor it starts in the background
+	runCmd.PersistentFlags().Bool("daemon", false, "record the environments for \"devkit ps\"")
+	_ = runCmd.PersistentFlags().MarkHidden("daemon")
+	// set by "devkit up" to hand the key of the secrets store over on stdin
+	runCmd.PersistentFlags().Bool("secrets-key-stdin", false, "read the key of the secrets store on stdin")
+	_ = runCmd.PersistentFlags().MarkHidden("secrets-key-stdin")
+
 	runCmd.AddCommand(NewEnvRun())
 	runCmd.AddCommand(NewProjectRun())
 
 	return runCmd
 }
+
+// runEnvironments supervises the given environments of project using the
+// flags of cmd
+func runEnvironments(cmd *cobra.Command, project *config.ProjectConfig, envs []config.EnvironmentConfig) error {
+	gracePeriod, _ := cmd.Flags().GetDuration("grace-period")
+	watch, _ := cmd.Flags().GetBool("watch")
+	noLogFiles, _ := cmd.Flags().GetBool("no-log-files")
+	if project != nil {
+		if err := project.Validate(); err != nil {
+			return err
+		}
+	}
+
+	// the secrets are unlocked before anything runs, rather than asking for
+	// the passphrase in the middle of the output of the environments
+	if keyStdin, _ := cmd.Flags().GetBool("secrets-key-stdin"); keyStdin {
+		key, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("error reading the key of the secrets store: %v", err)
+		}
+		if err := secrets.UseKey(key); err != nil {
+			return err
+		}
+	} else if needed, err := referencesSecrets(project, envs); err != nil {
+		return err
+	} else if needed {
+		if _, err := secrets.Unlocked(); err != nil {
+			return err
+		}
+	}
+
+	supervisor := NewSupervisor(gracePeriod)
+	supervisor.Watch = watch
+	supervisor.Project = project
+	supervisor.Logs = !noLogFiles
+
+	if daemon, _ := cmd.Flags().GetBool("daemon"); daemon {
+		tracker, err := newStateTracker(project)
+		if err != nil {
+			return err
+		}
+		defer tracker.Close()
+		supervisor.Tracker = tracker
+	}
+	for _, env := range envs {
+		supervisor.Add(env)
+	}
+
+	if len(envs) > 1 {
+		mux, err := newMux(cmd, envs)
+		if err != nil {
+			return err
+		}
+		supervisor.Output = mux
+	}
+	return supervisor.Run()
+}
+
+// newMux creates the output multiplexer of envs, filtered by the --only flag.
+// When stdin is a terminal the filter can also be changed while running.
+func newMux(cmd *cobra.Command, envs []config.EnvironmentConfig) (*Mux, error) {
+	names := make([]string, len(envs))
+	for i, env := range envs {
+		names[i] = env.Name
+	}
+	mux := NewMux(os.Stdout, os.Stderr, names)
+
+	only, _ := cmd.Flags().GetStringSlice("only")
+	for _, name := range only {
+		if !slices.Contains(names, name) {
+			return nil, fmt.Errorf("environment '%s' given to --only is not part of this run", name)
+		}
+	}
+	mux.SetFilter(only)
+
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		logrus.Info("Type ':only <env>[,<env>...]' to filter the output or ':all' to show every environment")
+		go mux.ReadFilterCommands(os.Stdin)
+	}
+	return mux, nil
+}
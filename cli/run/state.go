@@ -0,0 +1,245 @@
+package run
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/leodahal4/dev-kit/config"
+	"github.com/sirupsen/logrus"
+)
+
+// Statuses of a tracked environment
+const (
+	StatusWaiting    = "waiting"
+	StatusRunning    = "running"
+	StatusRestarting = "restarting"
+	StatusStale      = "stale"
+)
+
+// SupervisorState records a detached supervisor started by "devkit up"
+type SupervisorState struct {
+	ProjectID string    `json:"project_id"`
+	Project   string    `json:"project"`
+	Target    string    `json:"target"`
+	PID       int       `json:"pid"`
+	StartedAt time.Time `json:"started_at"`
+	Command   string    `json:"command"`
+	LogFile   string    `json:"log_file"`
+
+	path string
+}
+
+// EnvState records an environment run by a detached supervisor
+type EnvState struct {
+	ProjectID     string    `json:"project_id"`
+	Project       string    `json:"project"`
+	Env           string    `json:"env"`
+	Status        string    `json:"status"`
+	PID           int       `json:"pid"`
+	SupervisorPID int       `json:"supervisor_pid"`
+	StartedAt     time.Time `json:"started_at"`
+	Command       string    `json:"command"`
+	LogFile       string    `json:"log_file"`
+
+	path string
+}
+
+// Tracker records the lifecycle of the environments of a supervisor
+type Tracker interface {
+	// Waiting is called while an environment waits to be (re)started
+	Waiting(env config.EnvironmentConfig, status string)
+	// Started is called once the process of an environment started
+	Started(env config.EnvironmentConfig, cmd *exec.Cmd)
+	// Exited is called once an environment will not be started again
+	Exited(env config.EnvironmentConfig)
+}
+
+// stateDir returns the directory holding the state of the detached runs of a
+// project, ~/.dev-kit/run/<project-id>
+func stateDir(projectID string) (string, error) {
+	dir, err := config.DevKitPath("run", url.PathEscape(projectID))
+	if err != nil {
+		return "", err
+	}
+	return dir, os.MkdirAll(dir, os.ModePerm)
+}
+
+// supervisorFile returns the name of the state file of the supervisor of
+// target, which is empty for a whole project or the name of an environment
+func supervisorFile(target string) string {
+	if target == "" {
+		return "supervisor"
+	}
+	return "supervisor@" + url.PathEscape(target)
+}
+
+func envFile(env string) string {
+	return "env@" + url.PathEscape(env) + ".json"
+}
+
+// stateTracker writes the state of the environments of a detached supervisor
+type stateTracker struct {
+	dir     string
+	project *config.ProjectConfig
+}
+
+func newStateTracker(project *config.ProjectConfig) (*stateTracker, error) {
+	dir, err := stateDir(project.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &stateTracker{dir: dir, project: project}, nil
+}
+
+func (t *stateTracker) state(env config.EnvironmentConfig, status string) EnvState {
+	logFile, _ := envLogPath(t.project.ID, env.Name)
+	return EnvState{
+		ProjectID:     t.project.ID,
+		Project:       t.project.Name,
+		Env:           env.Name,
+		Status:        status,
+		SupervisorPID: os.Getpid(),
+		StartedAt:     time.Now(),
+		LogFile:       logFile,
+	}
+}
+
+func (t *stateTracker) Waiting(env config.EnvironmentConfig, status string) {
+	t.write(env, t.state(env, status))
+}
+
+func (t *stateTracker) Started(env config.EnvironmentConfig, cmd *exec.Cmd) {
+	state := t.state(env, StatusRunning)
+	state.PID = cmd.Process.Pid
+	state.Command = strings.Join(cmd.Args, " ")
+	t.write(env, state)
+}
+
+func (t *stateTracker) Exited(env config.EnvironmentConfig) {
+	if err := os.Remove(filepath.Join(t.dir, envFile(env.Name))); err != nil && !errors.Is(err, os.ErrNotExist) {
+		logrus.Debugf("removing state of %s: %v", env.Name, err)
+	}
+}
+
+func (t *stateTracker) write(env config.EnvironmentConfig, state EnvState) {
+	if err := writeState(filepath.Join(t.dir, envFile(env.Name)), state); err != nil {
+		logrus.Debugf("writing state of %s: %v", env.Name, err)
+	}
+}
+
+// Close removes the record of the supervisor running this tracker
+func (t *stateTracker) Close() {
+	supervisors, _, err := readStates(t.dir)
+	if err != nil {
+		return
+	}
+	for _, s := range supervisors {
+		if s.PID == os.Getpid() {
+			_ = os.Remove(s.path)
+		}
+	}
+}
+
+func writeState(path string, state any) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// readStates reads the supervisor and environment records of a state directory
+func readStates(dir string) ([]SupervisorState, []EnvState, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+
+	var supervisors []SupervisorState
+	var envs []EnvState
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch {
+		case strings.HasPrefix(name, "supervisor"):
+			var state SupervisorState
+			if err := json.Unmarshal(data, &state); err != nil {
+				return nil, nil, fmt.Errorf("error reading %s: %v", path, err)
+			}
+			state.path = path
+			supervisors = append(supervisors, state)
+		case strings.HasPrefix(name, "env@"):
+			var state EnvState
+			if err := json.Unmarshal(data, &state); err != nil {
+				return nil, nil, fmt.Errorf("error reading %s: %v", path, err)
+			}
+			state.path = path
+			envs = append(envs, state)
+		}
+	}
+	return supervisors, envs, nil
+}
+
+// readAllStates reads the records of every project
+func readAllStates() ([]SupervisorState, []EnvState, error) {
+	root, err := config.DevKitPath("run")
+	if err != nil {
+		return nil, nil, err
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+
+	var supervisors []SupervisorState
+	var envs []EnvState
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		s, e, err := readStates(filepath.Join(root, entry.Name()))
+		if err != nil {
+			return nil, nil, err
+		}
+		supervisors = append(supervisors, s...)
+		envs = append(envs, e...)
+	}
+	return supervisors, envs, nil
+}
+
+// liveStatus returns the status of an environment record, or StatusStale when
+// the processes it refers to died without devkit noticing
+func (e EnvState) liveStatus() string {
+	if !processAlive(e.SupervisorPID) {
+		return StatusStale
+	}
+	if e.Status == StatusRunning && !processAlive(e.PID) {
+		return StatusStale
+	}
+	return e.Status
+}
@@ -0,0 +1,478 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/leodahal4/dev-kit/config"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultGracePeriod is how long environments get to exit after being
+// signalled before they are killed
+const DefaultGracePeriod = 10 * time.Second
+
+// pipeWaitDelay bounds the wait for the output of a command which exited, as
+// processes it left behind may hold the pipes open forever
+const pipeWaitDelay = time.Second
+
+// Supervisor runs several environments side by side. Signals received by
+// devkit are forwarded to the process group of every environment, and
+// processes still alive after the grace period are killed.
+type Supervisor struct {
+	GracePeriod time.Duration
+
+	// Output prefixes the output of every environment when set, otherwise
+	// environments write straight to stdout and stderr
+	Output *Mux
+
+	// Watch restarts an environment whenever files below its path change
+	Watch bool
+
+	// Project resolves dependencies which are not part of the run, their
+	// readiness probe is still awaited when they have one
+	Project *config.ProjectConfig
+
+	// Tracker records the processes of the environments when set
+	Tracker Tracker
+
+	// Logs records the output of every environment of Project to its log file
+	// under ~/.dev-kit/logs, read back by "devkit logs"
+	Logs bool
+
+	mu       sync.Mutex
+	units    []*unit
+	stopping bool
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// unit is a single supervised environment
+type unit struct {
+	env config.EnvironmentConfig
+	cmd *exec.Cmd
+	log *envLog
+
+	// reloading is set while the process is stopped to be restarted after a
+	// file change, and reload wakes the unit when no process is running
+	reloading bool
+	reload    chan struct{}
+
+	// ready is closed once the readiness of the unit is settled, readyErr
+	// telling whether it became ready
+	probing   bool
+	ready     chan struct{}
+	readyErr  error
+	readyOnce sync.Once
+}
+
+// Failure records an environment which exited with an error
+type Failure struct {
+	Env string
+	Err error
+}
+
+// RunError aggregates the failures of a supervised run
+type RunError struct {
+	Failures []Failure
+}
+
+func (e *RunError) Error() string {
+	parts := make([]string, len(e.Failures))
+	for i, f := range e.Failures {
+		parts[i] = fmt.Sprintf("%s (%v)", f.Env, f.Err)
+	}
+	return fmt.Sprintf("%d environment(s) failed: %s", len(e.Failures), strings.Join(parts, ", "))
+}
+
+// ExitCode returns the exit code of the failed environment when only one
+// failed, and 1 otherwise
+func (e *RunError) ExitCode() int {
+	var exitErr *exec.ExitError
+	if len(e.Failures) == 1 && errors.As(e.Failures[0].Err, &exitErr) && exitErr.ExitCode() > 0 {
+		return exitErr.ExitCode()
+	}
+	return 1
+}
+
+func NewSupervisor(gracePeriod time.Duration) *Supervisor {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Supervisor{GracePeriod: gracePeriod, ctx: ctx, cancel: cancel}
+}
+
+// Add registers an environment to be started by Run
+func (s *Supervisor) Add(env config.EnvironmentConfig) *Supervisor {
+	s.units = append(s.units, &unit{env: env, reload: make(chan struct{}, 1), ready: make(chan struct{})})
+	return s
+}
+
+// Run starts every environment and blocks until all of them have exited. The
+// returned error is a *RunError listing the environments which failed.
+func (s *Supervisor) Run() error {
+	if len(s.units) == 0 {
+		return errors.New("there are no environments to run")
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	if s.Logs && s.Project != nil {
+		for _, u := range s.units {
+			log, err := openEnvLog(s.Project.ID, u.env.Name)
+			if err != nil {
+				return fmt.Errorf("logging %s: %v", u.env.Name, err)
+			}
+			defer log.Close()
+			u.log = log
+		}
+	}
+
+	if s.Watch {
+		for _, u := range s.units {
+			w, err := newWatcher(u.env)
+			if err != nil {
+				return fmt.Errorf("watching %s: %v", u.env.Name, err)
+			}
+			defer w.Close()
+
+			go w.run(s.ctx.Done(), func(changed string) {
+				logrus.Infof("%s: %s changed, restarting", u.env.Name, changed)
+				s.reload(u)
+			})
+		}
+	}
+
+	results := make(chan Failure, len(s.units))
+	for _, u := range s.units {
+		s.track(func(t Tracker) { t.Waiting(u.env, StatusWaiting) })
+		go func(u *unit) {
+			err := s.supervise(u)
+			s.track(func(t Tracker) { t.Exited(u.env) })
+			if err != nil {
+				s.settle(u, err)
+			} else {
+				s.settle(u, errors.New("exited before it was ready"))
+			}
+			results <- Failure{Env: u.env.Name, Err: err}
+		}(u)
+	}
+
+	var failures []Failure
+	var killTimer <-chan time.Time
+	for remaining := len(s.units); remaining > 0; {
+		select {
+		case res := <-results:
+			remaining--
+			if res.Err == nil {
+				logrus.Infof("%s exited", res.Env)
+				continue
+			}
+			if s.isStopping() {
+				logrus.Infof("%s stopped", res.Env)
+				continue
+			}
+			logrus.Errorf("%s exited: %v", res.Env, res.Err)
+			failures = append(failures, res)
+		case sig := <-signals:
+			if s.isStopping() {
+				logrus.Warnf("received %s again, killing %d environment(s)", sig, remaining)
+				s.kill()
+				continue
+			}
+			logrus.Infof("received %s, stopping %d environment(s)", sig, remaining)
+			s.stop(sig)
+			killTimer = time.After(s.GracePeriod)
+		case <-killTimer:
+			logrus.Warnf("grace period of %s expired, killing %d environment(s)", s.GracePeriod, remaining)
+			s.kill()
+		}
+	}
+
+	if len(failures) > 0 {
+		return &RunError{Failures: failures}
+	}
+	return nil
+}
+
+// supervise runs the unit and restarts it according to its restart policy,
+// or after its files changed in watch mode
+func (s *Supervisor) supervise(u *unit) error {
+	if err := s.waitDependencies(u); err != nil {
+		return err
+	}
+
+	restarts := newRestarter(u.env)
+	for {
+		started := time.Now()
+		err := s.start(u)
+		if s.isStopping() {
+			return err
+		}
+		if s.takeReload(u) {
+			restarts = newRestarter(u.env)
+			continue
+		}
+
+		delay, restart, err := restarts.next(err, time.Since(started))
+		if !restart {
+			if !s.Watch {
+				return err
+			}
+			if err != nil {
+				logrus.Errorf("%s exited: %v, waiting for changes", u.env.Name, err)
+			} else {
+				logrus.Infof("%s exited, waiting for changes", u.env.Name)
+			}
+			s.track(func(t Tracker) { t.Waiting(u.env, StatusWaiting) })
+			select {
+			case <-u.reload:
+				restarts = newRestarter(u.env)
+				continue
+			case <-s.ctx.Done():
+				return err
+			}
+		}
+
+		s.track(func(t Tracker) { t.Waiting(u.env, StatusRestarting) })
+		select {
+		case <-time.After(delay):
+		case <-u.reload:
+			restarts = newRestarter(u.env)
+		case <-s.ctx.Done():
+			return nil
+		}
+	}
+}
+
+// waitDependencies blocks until every dependency of the unit is ready
+func (s *Supervisor) waitDependencies(u *unit) error {
+	if len(u.env.DependsOn) == 0 {
+		return nil
+	}
+	logrus.Infof("%s is waiting for %s", u.env.Name, strings.Join(u.env.DependsOn, ", "))
+
+	for _, name := range u.env.DependsOn {
+		var deps []*unit
+		for _, dep := range s.units {
+			if dep.env.Name == name {
+				deps = append(deps, dep)
+			}
+		}
+
+		if len(deps) == 0 {
+			if err := s.waitExternal(u, name); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, dep := range deps {
+			select {
+			case <-dep.ready:
+			case <-s.ctx.Done():
+				return s.ctx.Err()
+			}
+			if dep.readyErr != nil {
+				return fmt.Errorf("dependency '%s' is not ready: %v", name, dep.readyErr)
+			}
+		}
+	}
+	return nil
+}
+
+// waitExternal waits for a dependency which is not part of this run, which is
+// only possible when it has a readiness probe
+func (s *Supervisor) waitExternal(u *unit, name string) error {
+	var dep *config.EnvironmentConfig
+	if s.Project != nil {
+		dep, _ = s.Project.FindEnvironment(name)
+	}
+	if dep == nil || !dep.Ready.IsSet() {
+		logrus.Warnf("%s depends on %s, which is not part of this run and has no readiness probe", u.env.Name, name)
+		return nil
+	}
+
+	if err := waitReady(s.ctx, *dep); err != nil {
+		return fmt.Errorf("dependency '%s' is not ready: %v", name, err)
+	}
+	return nil
+}
+
+// probe settles the readiness of the unit once its first process started
+func (s *Supervisor) probe(u *unit) {
+	err := waitReady(s.ctx, u.env)
+	switch {
+	case s.isStopping():
+	case err != nil:
+		logrus.Errorf("%s did not become ready: %v", u.env.Name, err)
+	case u.env.Ready.IsSet():
+		logrus.Infof("%s is ready", u.env.Name)
+	}
+	s.settle(u, err)
+}
+
+func (s *Supervisor) settle(u *unit, err error) {
+	u.readyOnce.Do(func() {
+		u.readyErr = err
+		close(u.ready)
+	})
+}
+
+// reload restarts the process of the unit, giving it the grace period to exit
+func (s *Supervisor) reload(u *unit) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopping || u.reloading {
+		return
+	}
+	if u.cmd == nil {
+		select {
+		case u.reload <- struct{}{}:
+		default:
+		}
+		return
+	}
+
+	u.reloading = true
+	cmd := u.cmd
+	if err := signalProcessGroup(cmd, syscall.SIGTERM); err != nil {
+		logrus.Debugf("signalling %s: %v", u.env.Name, err)
+	}
+	time.AfterFunc(s.GracePeriod, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if u.cmd == cmd {
+			_ = killProcessGroup(cmd)
+		}
+	})
+}
+
+// takeReload reports whether the last process was stopped by reload
+func (s *Supervisor) takeReload(u *unit) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reloading := u.reloading
+	u.reloading = false
+	select {
+	case <-u.reload:
+		reloading = true
+	default:
+	}
+	return reloading
+}
+
+// start runs the process of the unit and waits for it to exit
+func (s *Supervisor) start(u *unit) error {
+	cmd, err := buildCommand(s.Project, u.env)
+	if err != nil {
+		return err
+	}
+	var stdout, stderr io.Writer = os.Stdout, os.Stderr
+	if s.Output != nil {
+		out, errOut := s.Output.Writers(u.env.Name)
+		defer out.Flush()
+		defer errOut.Flush()
+		stdout, stderr = out, errOut
+	}
+	if u.log != nil {
+		out, errOut := u.log.Writers()
+		defer out.Flush()
+		defer errOut.Flush()
+		stdout, stderr = io.MultiWriter(stdout, out), io.MultiWriter(stderr, errOut)
+	}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	if s.Output != nil || u.log != nil {
+		cmd.WaitDelay = pipeWaitDelay
+	}
+	setProcessGroup(cmd)
+
+	s.mu.Lock()
+	if s.stopping {
+		s.mu.Unlock()
+		return nil
+	}
+	if err := cmd.Start(); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	u.cmd = cmd
+	s.track(func(t Tracker) { t.Started(u.env, cmd) })
+	if !u.probing {
+		u.probing = true
+		go s.probe(u)
+	}
+	s.mu.Unlock()
+
+	err = cmd.Wait()
+
+	s.mu.Lock()
+	u.cmd = nil
+	s.mu.Unlock()
+	return err
+}
+
+// track calls fn with the tracker of the supervisor, if any
+func (s *Supervisor) track(fn func(Tracker)) {
+	if s.Tracker != nil {
+		fn(s.Tracker)
+	}
+}
+
+func (s *Supervisor) isStopping() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stopping
+}
+
+// markStopping records that the supervisor is shutting down, the caller must
+// hold s.mu
+func (s *Supervisor) markStopping() {
+	if !s.stopping {
+		s.stopping = true
+		s.cancel()
+	}
+}
+
+// stop forwards sig to the process group of every started environment
+func (s *Supervisor) stop(sig os.Signal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.markStopping()
+	for _, u := range s.units {
+		if u.cmd == nil {
+			continue
+		}
+		if err := signalProcessGroup(u.cmd, sig); err != nil {
+			logrus.Debugf("signalling %s: %v", u.env.Name, err)
+		}
+	}
+}
+
+// kill kills the process group of every started environment
+func (s *Supervisor) kill() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.markStopping()
+	for _, u := range s.units {
+		if u.cmd == nil {
+			continue
+		}
+		if err := killProcessGroup(u.cmd); err != nil {
+			logrus.Debugf("killing %s: %v", u.env.Name, err)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package run
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/leodahal4/dev-kit/cli/secrets"
+	"github.com/leodahal4/dev-kit/cli/utils"
+	"github.com/leodahal4/dev-kit/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func NewTaskCommand() *cobra.Command {
+	taskCmd := &cobra.Command{
+		Use:   "task <project>[/<env>] [<task>]",
+		Short: "Run a task of an environment, or list the tasks",
+		Long: `Run a named task, such as build, test, lint or migrate, in the path of an
+environment, after the tasks it depends on. Given a project, the task is run in
+every environment having it, one after the other. Without a task name the
+available tasks are listed.
+
+Tasks are declared in the tasks of an environment, or of its project to share
+them between its environments:
+
+  tasks:
+    lint: golangci-lint run
+    test:
+      description: run the unit tests
+      command: go test ./...
+      depends_on: [build]
+
+The build and test commands of an environment are its build and test tasks,
+the test task depending on the build one, unless tasks with these names are
+declared.`,
+		Example:           "  devkit task shop\n  devkit task shop/api test\n  devkit task shop migrate",
+		Args:              cobra.RangeArgs(1, 2),
+		ValidArgsFunction: completeTasks,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			utils.ParseAndSaveCommand(cmd, args)
+		},
+		RunE: RunTask,
+	}
+
+	utils.AddOutputFlag(taskCmd)
+
+	return taskCmd
+}
+
+// completeTasks completes the project or environment, then its tasks
+func completeTasks(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return utils.CompleteTargets(cmd, args, toComplete)
+	}
+	if len(args) > 1 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	project, envs, err := config.GetConfig().FindTargets(args[0])
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	seen := map[string]bool{}
+	var names []string
+	for _, env := range envs {
+		for _, task := range config.SortedTasks(project.EnvironmentTasks(&env)) {
+			if !seen[task.Name] && strings.HasPrefix(task.Name, toComplete) {
+				seen[task.Name] = true
+				names = append(names, task.Name+"\t"+task.Description)
+			}
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// RunTask runs the task named by the second argument in the environments
+// referenced by the first one, or lists their tasks without a task name
+func RunTask(cmd *cobra.Command, args []string) error {
+	project, envs, err := config.GetConfig().FindTargets(args[0])
+	if err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		return listTasks(cmd, project, envs)
+	}
+
+	name := args[1]
+	type run struct {
+		env  config.EnvironmentConfig
+		plan []config.Task
+	}
+	var runs []run
+	for _, env := range envs {
+		tasks := project.EnvironmentTasks(&env)
+		if _, ok := tasks[name]; !ok && len(envs) > 1 {
+			// a task of some environments of the project only
+			continue
+		}
+		plan, err := config.TaskPlan(tasks, name)
+		if err != nil {
+			return fmt.Errorf("environment '%s': %v, see \"devkit task %s/%s\"", env.Name, err, project.Name, env.Name)
+		}
+		runs = append(runs, run{env: env, plan: plan})
+	}
+	if len(runs) == 0 {
+		return fmt.Errorf("no environment of project '%s' has a task '%s', see \"devkit task %s\"", project.Name, name, project.Name)
+	}
+
+	var runEnvs []config.EnvironmentConfig
+	for _, r := range runs {
+		runEnvs = append(runEnvs, r.env)
+	}
+	if needed, err := referencesSecrets(project, runEnvs); err != nil {
+		return err
+	} else if needed {
+		if _, err := secrets.Unlocked(); err != nil {
+			return err
+		}
+	}
+
+	var mux *Mux
+	if len(runs) > 1 {
+		names := make([]string, len(runs))
+		for i, r := range runs {
+			names[i] = r.env.Name
+		}
+		mux = NewMux(os.Stdout, os.Stderr, names)
+	}
+	for _, r := range runs {
+		for _, task := range r.plan {
+			if err := runTask(project, r.env, task, mux); err != nil {
+				return &RunError{Failures: []Failure{{Env: r.env.Name, Err: fmt.Errorf("task %s: %w", task.Name, err)}}}
+			}
+		}
+	}
+	return nil
+}
+
+// runTask runs a task through the shell in the path of env, prefixing its
+// output through mux when it is set
+func runTask(project *config.ProjectConfig, env config.EnvironmentConfig, task config.Task, mux *Mux) error {
+	logrus.Infof("%s: running %s: %s", env.Name, task.Name, task.Command)
+	start := time.Now()
+
+	cmd := shellCommand(task.Command)
+	cmd.Dir = env.Path
+	var err error
+	if cmd.Env, err = environ(project, env); err != nil {
+		return err
+	}
+	var stdout, stderr io.Writer = os.Stdout, os.Stderr
+	if mux != nil {
+		out, errOut := mux.Writers(env.Name)
+		defer out.Flush()
+		defer errOut.Flush()
+		stdout, stderr = out, errOut
+		cmd.WaitDelay = pipeWaitDelay
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, stdout, stderr
+	if err := cmd.Run(); err != nil {
+		return err
+	}
+	logrus.Infof("%s: %s done in %s", env.Name, task.Name, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// taskEntry is a task of an environment as listed
+type taskEntry struct {
+	Env string `json:"environment"`
+	config.Task
+}
+
+// listTasks prints the tasks of envs
+func listTasks(cmd *cobra.Command, project *config.ProjectConfig, envs []config.EnvironmentConfig) error {
+	format, err := utils.OutputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	entries := []taskEntry{}
+	for _, env := range envs {
+		tasks := project.EnvironmentTasks(&env)
+		for _, task := range config.SortedTasks(tasks) {
+			if _, err := config.TaskPlan(tasks, task.Name); err != nil {
+				logrus.Warnf("environment '%s': %v", env.Name, err)
+			}
+			entries = append(entries, taskEntry{Env: env.Name, Task: task})
+		}
+	}
+	if format == utils.OutputJSON {
+		return utils.PrintJSON(cmd.OutOrStdout(), entries)
+	}
+	if len(entries) == 0 {
+		return errors.New("there are no tasks, declare them in the tasks of the environments or of the project")
+	}
+
+	w := utils.NewTable(cmd.OutOrStdout())
+	fmt.Fprintln(w, "ENV\tTASK\tDEPENDS ON\tFROM\tDESCRIPTION")
+	for _, e := range entries {
+		description := e.Description
+		if description == "" {
+			description = e.Command
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.Env, e.Name, utils.Cell(strings.Join(e.DependsOn, ", ")), e.Source, description)
+	}
+	return w.Flush()
+}
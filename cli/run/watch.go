@@ -0,0 +1,149 @@
+package run
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/leodahal4/dev-kit/config"
+	"github.com/sirupsen/logrus"
+)
+
+// watchDebounce is how long a burst of changes has to settle before the
+// environment is restarted
+const watchDebounce = 300 * time.Millisecond
+
+// defaultWatchExcludes are never watched, whatever the environment declares
+var defaultWatchExcludes = []string{"**/.git", "**/.git/**", "**/*.swp", "**/*~", "**/.DS_Store"}
+
+// watcher watches the files below the path of an environment
+type watcher struct {
+	root    string
+	include []string
+	exclude []string
+	ignore  gitignore
+	fs      *fsnotify.Watcher
+}
+
+func newWatcher(env config.EnvironmentConfig) (*watcher, error) {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &watcher{
+		root:    env.Path,
+		include: env.Watch.Include,
+		exclude: append(append([]string{}, defaultWatchExcludes...), env.Watch.Exclude...),
+		fs:      fsWatcher,
+	}
+	if err := w.addTree(env.Path); err != nil {
+		_ = fsWatcher.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *watcher) Close() error {
+	return w.fs.Close()
+}
+
+// addTree watches dir and every directory below it which is not ignored
+func (w *watcher) addTree(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+
+		rel := w.rel(path)
+		if rel != "." && (w.ignore.ignored(rel, true) || w.excluded(rel)) {
+			return filepath.SkipDir
+		}
+		w.ignore.load(w.root, rel)
+		return w.fs.Add(path)
+	})
+}
+
+// rel returns path relative to the watched root, slash separated
+func (w *watcher) rel(path string) string {
+	rel, err := filepath.Rel(w.root, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+func (w *watcher) excluded(rel string) bool {
+	for _, pattern := range w.exclude {
+		if matchGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// relevant reports whether a change of the file at rel restarts the environment
+func (w *watcher) relevant(rel string) bool {
+	if w.ignore.ignored(rel, false) || w.excluded(rel) {
+		return false
+	}
+	if len(w.include) == 0 {
+		return true
+	}
+	for _, pattern := range w.include {
+		if matchGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// run calls onChange with the last changed file once a burst of changes has
+// settled for watchDebounce, until done is closed
+func (w *watcher) run(done <-chan struct{}, onChange func(changed string)) {
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+
+	var changed string
+	for {
+		select {
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+
+			rel := w.rel(event.Name)
+			if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+				if event.Has(fsnotify.Create) && !w.ignore.ignored(rel, true) && !w.excluded(rel) {
+					if err := w.addTree(event.Name); err != nil {
+						logrus.Debugf("watching %s: %v", event.Name, err)
+					}
+				}
+				continue
+			}
+			if !w.relevant(rel) {
+				continue
+			}
+			changed = rel
+			timer.Reset(watchDebounce)
+		case <-timer.C:
+			onChange(changed)
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+			logrus.Debugf("watching %s: %v", w.root, err)
+		case <-done:
+			timer.Stop()
+			return
+		}
+	}
+}
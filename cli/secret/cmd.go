@@ -0,0 +1,32 @@
+package secret
+
+import (
+	"github.com/leodahal4/dev-kit/cli/utils"
+	"github.com/spf13/cobra"
+)
+
+func NewSecretCommand() *cobra.Command {
+	secretCmd := &cobra.Command{
+		Use:   "secret",
+		Short: "Manage the secrets of projects and environments",
+		Long: `Keep passwords and tokens in an encrypted store under ~/.dev-kit instead of the
+configuration. A variable set to secret://<name> in the env of a project or
+environment gets the value of the secret when the environment is started, the
+secret of the environment taking precedence over the one of its project.
+
+The store is encrypted with a key derived from a passphrase, asked for when a
+secret is read or written, or with the key file set with the secrets_key_file
+setting.`,
+		Example: "  devkit secret set shop/api DB_PASSWORD\n  devkit env vars shop/api  # DB_PASSWORD: secret://DB_PASSWORD",
+		PreRun: func(cmd *cobra.Command, args []string) {
+			utils.ParseAndSaveCommand(cmd, args)
+		},
+	}
+
+	secretCmd.AddCommand(NewSetCommand())
+	secretCmd.AddCommand(NewGetCommand())
+	secretCmd.AddCommand(NewListCommand())
+	secretCmd.AddCommand(NewRemoveCommand())
+
+	return secretCmd
+}
@@ -0,0 +1,83 @@
+package secret
+
+import (
+	"fmt"
+
+	"github.com/leodahal4/dev-kit/cli/secrets"
+	"github.com/leodahal4/dev-kit/cli/utils"
+	"github.com/leodahal4/dev-kit/config"
+	"github.com/spf13/cobra"
+)
+
+func NewListCommand() *cobra.Command {
+	listCmd := &cobra.Command{
+		Use:               "list [<project>[/<env>]]",
+		Aliases:           []string{"ls"},
+		Short:             "List the secrets, without their values",
+		Args:              cobra.MaximumNArgs(1),
+		Annotations:       utils.ConfigOnly(),
+		ValidArgsFunction: completeScopes,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			utils.ParseAndSaveCommand(cmd, args)
+		},
+		RunE: List,
+	}
+
+	utils.AddOutputFlag(listCmd)
+
+	return listCmd
+}
+
+// secretEntry is a secret as listed, without its value
+type secretEntry struct {
+	ProjectID string `json:"project_id"`
+	Project   string `json:"project"`
+	Env       string `json:"env,omitempty"`
+	Name      string `json:"name"`
+	Updated   string `json:"updated"`
+}
+
+// List prints the secrets of every project, or of the project or environment
+// given as argument. The store does not need to be unlocked.
+func List(cmd *cobra.Command, args []string) error {
+	format, err := utils.OutputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	var filter scope
+	if len(args) > 0 {
+		if filter, err = findScope(args[0]); err != nil {
+			return err
+		}
+	}
+	store, err := secrets.Open()
+	if err != nil {
+		return err
+	}
+
+	cfg := config.GetConfig()
+	entries := []secretEntry{}
+	for _, secret := range store.List(filter.ProjectID, filter.Env) {
+		project := secret.Project
+		if p, err := cfg.FindProject(secret.Project); err == nil {
+			project = p.Name
+		}
+		entries = append(entries, secretEntry{
+			ProjectID: secret.Project,
+			Project:   project,
+			Env:       secret.Env,
+			Name:      secret.Name,
+			Updated:   secret.Updated.Format("2006-01-02 15:04"),
+		})
+	}
+	if format == utils.OutputJSON {
+		return utils.PrintJSON(cmd.OutOrStdout(), entries)
+	}
+
+	w := utils.NewTable(cmd.OutOrStdout())
+	fmt.Fprintln(w, "PROJECT\tENV\tNAME\tREFERENCE\tUPDATED")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s%s\t%s\n", e.Project, utils.Cell(e.Env), e.Name, secrets.RefPrefix, e.Name, e.Updated)
+	}
+	return w.Flush()
+}
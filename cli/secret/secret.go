@@ -0,0 +1,199 @@
+package secret
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/leodahal4/dev-kit/cli/secrets"
+	"github.com/leodahal4/dev-kit/cli/utils"
+	"github.com/leodahal4/dev-kit/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+func NewSetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <project>[/<env>] <name> [value]",
+		Short: "Add or change a secret",
+		Long: `Add or change a secret of a project, shared by its environments, or of a single
+environment. The value is asked for without echoing it when it is not given,
+or read from stdin when it is not a terminal, which keeps it out of the shell
+history.`,
+		Example:           "  devkit secret set shop DB_PASSWORD\n  echo -n token | devkit secret set shop/api API_TOKEN",
+		Args:              cobra.RangeArgs(2, 3),
+		Annotations:       utils.ConfigOnly(),
+		ValidArgsFunction: completeScopes,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			utils.ParseAndSaveCommand(cmd, args)
+		},
+		RunE: Set,
+	}
+}
+
+func NewGetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:               "get <project>[/<env>] <name>",
+		Short:             "Print the value of a secret",
+		Args:              cobra.ExactArgs(2),
+		Annotations:       utils.ConfigOnly(),
+		ValidArgsFunction: completeScopes,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			utils.ParseAndSaveCommand(cmd, args)
+		},
+		RunE: Get,
+	}
+}
+
+func NewRemoveCommand() *cobra.Command {
+	rmCmd := &cobra.Command{
+		Use:               "rm <project>[/<env>] <name>",
+		Aliases:           []string{"remove"},
+		Short:             "Remove a secret",
+		Args:              cobra.ExactArgs(2),
+		Annotations:       utils.ConfigOnly(),
+		ValidArgsFunction: completeScopes,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			utils.ParseAndSaveCommand(cmd, args)
+		},
+		RunE: Remove,
+	}
+
+	utils.AddConfirmFlag(rmCmd)
+
+	return rmCmd
+}
+
+// completeScopes completes the first argument with projects and environments
+func completeScopes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	projects, directive := utils.CompleteProjects(cmd, args, toComplete)
+	envs, _ := utils.CompleteEnvironments(cmd, args, toComplete)
+	return append(projects, envs...), directive
+}
+
+// scope is the project, and possibly the environment, owning secrets
+type scope struct {
+	ProjectID string
+	Env       string
+	label     string
+}
+
+// findScope resolves a "<project>" or "<project>/<env>" reference
+func findScope(ref string) (scope, error) {
+	cfg := config.GetConfig()
+	if strings.Contains(ref, "/") {
+		project, env, err := cfg.FindEnvironment(ref)
+		if err != nil {
+			return scope{}, err
+		}
+		return scope{ProjectID: project.ID, Env: env.Name, label: project.Name + "/" + env.Name}, nil
+	}
+	project, err := cfg.FindProject(ref)
+	if err != nil {
+		return scope{}, err
+	}
+	return scope{ProjectID: project.ID, label: project.Name}, nil
+}
+
+// Set adds or changes the secret named by the second argument
+func Set(cmd *cobra.Command, args []string) error {
+	scope, err := findScope(args[0])
+	if err != nil {
+		return err
+	}
+	name := args[1]
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid secret name '%s', use letters, digits, '_', '.' and '-'", name)
+	}
+
+	store, err := secrets.Open()
+	if err != nil {
+		return err
+	}
+	if err := store.Unlock(); err != nil {
+		return err
+	}
+
+	var value string
+	if len(args) == 3 {
+		value = args[2]
+	} else if value, err = readValue(name); err != nil {
+		return err
+	}
+	if err := store.Set(scope.ProjectID, scope.Env, name, value); err != nil {
+		return err
+	}
+	logrus.Infof("Saved secret '%s' of %s, reference it as %s%s in its env", name, scope.label, secrets.RefPrefix, name)
+	return nil
+}
+
+// readValue asks for the value of a secret without echoing it, or reads it
+// from stdin when it is not a terminal
+func readValue(name string) (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("error reading the value: %v", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	fmt.Fprintf(os.Stderr, "Value of %s: ", name)
+	// the value is kept as typed, leading and trailing spaces included
+	data, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("error reading the value: %v", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// Get prints the secret named by the second argument
+func Get(cmd *cobra.Command, args []string) error {
+	scope, err := findScope(args[0])
+	if err != nil {
+		return err
+	}
+	store, err := secrets.Open()
+	if err != nil {
+		return err
+	}
+	if len(store.List(scope.ProjectID, scope.Env)) == 0 {
+		return fmt.Errorf("%s has no secrets", scope.label)
+	}
+	if err := store.Unlock(); err != nil {
+		return err
+	}
+	value, err := store.Get(scope.ProjectID, scope.Env, args[1])
+	if err != nil {
+		return fmt.Errorf("%v in %s", err, scope.label)
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), value)
+	return nil
+}
+
+// Remove removes the secret named by the second argument
+func Remove(cmd *cobra.Command, args []string) error {
+	scope, err := findScope(args[0])
+	if err != nil {
+		return err
+	}
+	store, err := secrets.Open()
+	if err != nil {
+		return err
+	}
+
+	confirmed, err := utils.Confirm(cmd, fmt.Sprintf("Remove secret '%s' of %s?", args[1], scope.label))
+	if err != nil || !confirmed {
+		return err
+	}
+	if err := store.Remove(scope.ProjectID, scope.Env, args[1]); err != nil {
+		return fmt.Errorf("%v in %s", err, scope.label)
+	}
+	logrus.Infof("Removed secret '%s' of %s", args[1], scope.label)
+	return nil
+}
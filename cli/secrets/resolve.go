@@ -0,0 +1,106 @@
+package secrets
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RefPrefix starts the values of variables referencing a secret, such as
+// secret://DB_PASSWORD
+const RefPrefix = "secret://"
+
+var (
+	unlockedMu sync.Mutex
+	unlocked   *Store
+)
+
+// Ref returns the name of the secret referenced by value
+func Ref(value string) (string, bool) {
+	name, ok := strings.CutPrefix(value, RefPrefix)
+	return name, ok && name != ""
+}
+
+// Referenced reports whether one of the values references a secret
+func Referenced(vars map[string]string) bool {
+	for _, value := range vars {
+		if _, ok := Ref(value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Unlocked returns the store, unlocked once for the whole process so that the
+// passphrase is asked for at most once
+func Unlocked() (*Store, error) {
+	unlockedMu.Lock()
+	defer unlockedMu.Unlock()
+	if unlocked != nil {
+		return unlocked, nil
+	}
+
+	store, err := Open()
+	if err != nil {
+		return nil, err
+	}
+	if len(store.file.Secrets) == 0 {
+		return nil, fmt.Errorf("there are no secrets, add them with \"devkit secret set\"")
+	}
+	if err := store.Unlock(); err != nil {
+		return nil, err
+	}
+	unlocked = store
+	return unlocked, nil
+}
+
+// UseKey unlocks the store of the process with a key returned by Store.Key,
+// handed over by another devkit process
+func UseKey(encoded string) error {
+	unlockedMu.Lock()
+	defer unlockedMu.Unlock()
+
+	store, err := Open()
+	if err != nil {
+		return err
+	}
+	if err := store.UseKey(encoded); err != nil {
+		return err
+	}
+	unlocked = store
+	return nil
+}
+
+// Resolve replaces the values of vars referencing a secret with the value of
+// the secret, the one of the environment env or else the one of project
+func Resolve(project, env string, vars map[string]string) error {
+	if !Referenced(vars) {
+		return nil
+	}
+	store, err := Unlocked()
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		name, ok := Ref(vars[k])
+		if !ok {
+			continue
+		}
+		value, found, err := store.Lookup(project, env, name)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("variable %s references the secret '%s', which does not exist, add it with \"devkit secret set\"", k, name)
+		}
+		vars[k] = value
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package secrets
+
+import "fmt"
+
+// MoveEnvironment runs change, which renames or moves the environment env of
+// project to the environment toEnv of toProject in the configuration, and
+// moves the secrets of the environment along. The store is unlocked before
+// change when the environment has secrets, so that a wrong passphrase leaves
+// the configuration untouched.
+func MoveEnvironment(project, env, toProject, toEnv string, change func() error) error {
+	if project == toProject && env == toEnv {
+		return change()
+	}
+	store, err := Open()
+	if err != nil {
+		return err
+	}
+	hasSecrets := len(store.List(project, env)) > 0
+	if hasSecrets {
+		if err := store.Unlock(); err != nil {
+			return err
+		}
+	}
+	if err := change(); err != nil {
+		return err
+	}
+
+	switch {
+	case hasSecrets:
+		_, err = store.MoveScope(project, env, toProject, toEnv)
+	case len(store.List(toProject, toEnv)) > 0:
+		// the secrets of an environment removed before are not inherited
+		_, err = store.RemoveScope(toProject, toEnv)
+	}
+	if err != nil {
+		return fmt.Errorf("error moving the secrets of environment '%s': %v", env, err)
+	}
+	return nil
+}
+
+// Forget deletes the secrets of a removed environment, or of a removed project
+// and its environments when env is empty
+func Forget(project, env string) error {
+	store, err := Open()
+	if err != nil {
+		return err
+	}
+	if len(store.List(project, env)) == 0 {
+		return nil
+	}
+	if _, err := store.RemoveScope(project, env); err != nil {
+		return fmt.Errorf("error removing the secrets: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,467 @@
+package secrets
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/leodahal4/dev-kit/cli/utils"
+	"github.com/leodahal4/dev-kit/config"
+	"github.com/mitchellh/go-homedir"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/term"
+)
+
+const (
+	fileName = "secrets.json"
+
+	// key derivations of a store
+	kdfArgon2id = "argon2id"
+	kdfKeyFile  = "keyfile"
+
+	keySize = 32
+
+	// checkValue is encrypted along with the secrets to tell whether a key is
+	// the one of the store
+	checkValue = "devkit secrets"
+)
+
+// argon2id parameters of new stores
+const (
+	argonTime    = 3
+	argonMemory  = 64 * 1024
+	argonThreads = 4
+)
+
+// Secret is an encrypted value, scoped to a project or to one of its
+// environments
+type Secret struct {
+	Project string `json:"project"`
+
+	// Env is empty for the secrets of the project, which are shared by its
+	// environments
+	Env     string    `json:"env,omitempty"`
+	Name    string    `json:"name"`
+	Updated time.Time `json:"updated"`
+
+	// Value is the nonce followed by the encrypted value
+	Value []byte `json:"value"`
+}
+
+// storeFile is the content of ~/.dev-kit/secrets.json, only the values are
+// encrypted
+type storeFile struct {
+	Version int    `json:"version"`
+	KDF     string `json:"kdf"`
+
+	// Salt and the argon2id parameters derive the key from the passphrase
+	Salt    []byte `json:"salt,omitempty"`
+	Time    uint32 `json:"time,omitempty"`
+	Memory  uint32 `json:"memory,omitempty"`
+	Threads uint8  `json:"threads,omitempty"`
+
+	Check   []byte   `json:"check,omitempty"`
+	Secrets []Secret `json:"secrets"`
+}
+
+// Store holds the secrets of every project, encrypted with AES-GCM. Listing
+// them does not need the key, reading and writing them does, see Unlock.
+type Store struct {
+	path string
+	file storeFile
+	key  []byte
+}
+
+// Open reads the store, which is empty until the first secret is set
+func Open() (*Store, error) {
+	path, err := config.DevKitPath(fileName)
+	if err != nil {
+		return nil, err
+	}
+	file, err := readStore(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{path: path, file: file}, nil
+}
+
+func readStore(path string) (storeFile, error) {
+	file := storeFile{Version: 1}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return file, nil
+	}
+	if err != nil {
+		return file, fmt.Errorf("error reading the secrets store: %v", err)
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return file, fmt.Errorf("error reading the secrets store %s: %v", path, err)
+	}
+	return file, nil
+}
+
+// initialized reports whether the store has a key, which is chosen when the
+// first secret is set
+func (f storeFile) initialized() bool {
+	return f.KDF != ""
+}
+
+// Path returns the file of the store
+func (s *Store) Path() string {
+	return s.path
+}
+
+// List returns the secrets of a project, or of one of its environments when env
+// is set, or every secret when project is empty
+func (s *Store) List(project, env string) []Secret {
+	var secrets []Secret
+	for _, secret := range s.file.Secrets {
+		if project != "" && (secret.Project != project || env != "" && secret.Env != env) {
+			continue
+		}
+		secrets = append(secrets, secret)
+	}
+	return secrets
+}
+
+// Unlock derives the key of the store, from the file set with the
+// secrets_key_file setting or from a passphrase read on the terminal. A new
+// store is encrypted with the key file when it is set, generating it if needed,
+// and with a passphrase, asked for twice, otherwise.
+func (s *Store) Unlock() error {
+	if s.key != nil {
+		return nil
+	}
+	keyFile, err := homedir.Expand(config.GetConfig().SECRETS_KEY_FILE)
+	if err != nil {
+		return err
+	}
+
+	var key []byte
+	switch {
+	case !s.file.initialized() && keyFile != "":
+		if key, err = readKeyFile(keyFile, true); err != nil {
+			return err
+		}
+		s.file.KDF = kdfKeyFile
+	case !s.file.initialized():
+		passphrase, err := readPassphrase("New passphrase of the secrets store: ")
+		if err != nil {
+			return err
+		}
+		confirmation, err := readPassphrase("Repeat the passphrase: ")
+		if err != nil {
+			return err
+		}
+		if passphrase != confirmation {
+			return errors.New("the passphrases do not match")
+		}
+		s.file.KDF, s.file.Time, s.file.Memory, s.file.Threads = kdfArgon2id, argonTime, argonMemory, argonThreads
+		s.file.Salt = make([]byte, 16)
+		if _, err := rand.Read(s.file.Salt); err != nil {
+			return err
+		}
+		key = s.file.derive(passphrase)
+	case s.file.KDF == kdfKeyFile:
+		if keyFile == "" {
+			return fmt.Errorf("the secrets store is encrypted with a key file, set it with \"devkit config set secrets_key_file <path>\" or %sSECRETS_KEY_FILE", config.EnvPrefix)
+		}
+		if key, err = readKeyFile(keyFile, false); err != nil {
+			return err
+		}
+	case s.file.KDF == kdfArgon2id:
+		passphrase, err := readPassphrase("Passphrase of the secrets store: ")
+		if err != nil {
+			return err
+		}
+		key = s.file.derive(passphrase)
+	default:
+		return fmt.Errorf("unknown key derivation '%s' in the secrets store", s.file.KDF)
+	}
+
+	if s.file.Check == nil {
+		if s.file.Check, err = seal(key, []byte(checkValue), []byte("check")); err != nil {
+			return err
+		}
+	}
+	return s.useKey(key)
+}
+
+// Key returns the key of an unlocked store, encoded to be handed to UseKey
+func (s *Store) Key() string {
+	return base64.StdEncoding.EncodeToString(s.key)
+}
+
+// UseKey unlocks the store with a key returned by Key
+func (s *Store) UseKey(encoded string) error {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return fmt.Errorf("invalid key of the secrets store: %v", err)
+	}
+	return s.useKey(key)
+}
+
+func (s *Store) useKey(key []byte) error {
+	check, err := unseal(key, s.file.Check, []byte("check"))
+	if err != nil || string(check) != checkValue {
+		if s.file.KDF == kdfKeyFile {
+			return errors.New("the key file is not the one of the secrets store")
+		}
+		return errors.New("wrong passphrase")
+	}
+	s.key = key
+	return nil
+}
+
+func (f storeFile) derive(passphrase string) []byte {
+	return argon2.IDKey([]byte(passphrase), f.Salt, f.Time, f.Memory, f.Threads, keySize)
+}
+
+// readPassphrase reads a passphrase on the terminal without echoing it
+func readPassphrase(prompt string) (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", errors.New("the passphrase of the secrets store cannot be asked for without a terminal, use a key file with the secrets_key_file setting instead")
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	passphrase, err := utils.New(runtime.GOOS).ReadPassword()
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("error reading the passphrase: %v", err)
+	}
+	if passphrase == "" {
+		return "", errors.New("the passphrase cannot be empty")
+	}
+	return passphrase, nil
+}
+
+// readKeyFile reads a base64 encoded key, generating the file first when
+// create is set and it does not exist
+func readKeyFile(path string, create bool) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && create {
+		key := make([]byte, keySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0600); err != nil {
+			return nil, fmt.Errorf("error creating the key file: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "Created the key file %s, the secrets cannot be read without it\n", path)
+		return key, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading the key file: %v", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != keySize {
+		return nil, fmt.Errorf("%s is not a key file, it has to hold %d base64 encoded bytes", path, keySize)
+	}
+	return key, nil
+}
+
+// Get returns the value of a secret of the project or environment
+func (s *Store) Get(project, env, name string) (string, error) {
+	i := s.find(project, env, name)
+	if i < 0 {
+		return "", fmt.Errorf("secret '%s' does not exist", name)
+	}
+	return s.decrypt(s.file.Secrets[i])
+}
+
+// Lookup returns the value of the secret name seen from an environment, its
+// own secret or else the one of its project
+func (s *Store) Lookup(project, env, name string) (string, bool, error) {
+	for _, scope := range []string{env, ""} {
+		if i := s.find(project, scope, name); i >= 0 {
+			value, err := s.decrypt(s.file.Secrets[i])
+			return value, true, err
+		}
+		if env == "" {
+			break
+		}
+	}
+	return "", false, nil
+}
+
+func (s *Store) find(project, env, name string) int {
+	return slices.IndexFunc(s.file.Secrets, func(secret Secret) bool {
+		return secret.Project == project && secret.Env == env && secret.Name == name
+	})
+}
+
+func (s *Store) decrypt(secret Secret) (string, error) {
+	if s.key == nil {
+		return "", errors.New("the secrets store is locked")
+	}
+	value, err := unseal(s.key, secret.Value, secret.additionalData())
+	if err != nil {
+		return "", fmt.Errorf("error decrypting secret '%s': %v", secret.Name, err)
+	}
+	return string(value), nil
+}
+
+// additionalData binds the encrypted value to the scope and name of the
+// secret, so that values cannot be swapped in the file
+func (secret Secret) additionalData() []byte {
+	return []byte(secret.Project + "/" + secret.Env + "/" + secret.Name)
+}
+
+// Set encrypts and saves a secret of the project or environment, the store has
+// to be unlocked
+func (s *Store) Set(project, env, name, value string) error {
+	if s.key == nil {
+		return errors.New("the secrets store is locked")
+	}
+	secret := Secret{Project: project, Env: env, Name: name, Updated: time.Now()}
+	var err error
+	if secret.Value, err = seal(s.key, []byte(value), secret.additionalData()); err != nil {
+		return err
+	}
+	return s.update(func() error {
+		if i := s.find(project, env, name); i >= 0 {
+			s.file.Secrets[i] = secret
+		} else {
+			s.file.Secrets = append(s.file.Secrets, secret)
+		}
+		return nil
+	})
+}
+
+// Remove deletes a secret of the project or environment
+func (s *Store) Remove(project, env, name string) error {
+	return s.update(func() error {
+		i := s.find(project, env, name)
+		if i < 0 {
+			return fmt.Errorf("secret '%s' does not exist", name)
+		}
+		s.file.Secrets = slices.Delete(s.file.Secrets, i, i+1)
+		return nil
+	})
+}
+
+// RemoveScope deletes the secrets of an environment, or of a project and its
+// environments when env is empty, and returns how many were deleted
+func (s *Store) RemoveScope(project, env string) (int, error) {
+	removed := 0
+	err := s.update(func() error {
+		n := len(s.file.Secrets)
+		s.file.Secrets = slices.DeleteFunc(s.file.Secrets, func(secret Secret) bool {
+			return secret.Project == project && (env == "" || secret.Env == env)
+		})
+		removed = n - len(s.file.Secrets)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
+// MoveScope moves the secrets of the environment env of project to the
+// environment toEnv of toProject, replacing the secrets left there by an
+// environment removed earlier. The values are bound to their scope, so the
+// store has to be unlocked to encrypt them again.
+func (s *Store) MoveScope(project, env, toProject, toEnv string) (int, error) {
+	if s.key == nil {
+		return 0, errors.New("the secrets store is locked")
+	}
+	moved := 0
+	err := s.update(func() error {
+		var kept, secrets []Secret
+		for _, secret := range s.file.Secrets {
+			switch {
+			case secret.Project == project && secret.Env == env:
+				value, err := s.decrypt(secret)
+				if err != nil {
+					return err
+				}
+				secret.Project, secret.Env = toProject, toEnv
+				if secret.Value, err = seal(s.key, []byte(value), secret.additionalData()); err != nil {
+					return err
+				}
+				secrets = append(secrets, secret)
+			case secret.Project == toProject && secret.Env == toEnv:
+				// left behind, no environment had this scope
+			default:
+				kept = append(kept, secret)
+			}
+		}
+		s.file.Secrets, moved = append(kept, secrets...), len(secrets)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return moved, nil
+}
+
+// update reads the store again while holding its lock, so that the secrets
+// saved by other devkit processes in the meantime are kept, changes it with fn
+// and saves it
+func (s *Store) update(fn func() error) error {
+	return config.WithLock(s.path, func() error {
+		latest, err := readStore(s.path)
+		if err != nil {
+			return err
+		}
+		if latest.initialized() {
+			if s.key != nil && !bytes.Equal(latest.Check, s.file.Check) {
+				return errors.New("the secrets store was created by another devkit in the meantime, try again")
+			}
+			s.file = latest
+		} else {
+			s.file.Secrets = nil
+		}
+
+		if err := fn(); err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(s.file, "", "  ")
+		if err != nil {
+			return err
+		}
+		return config.WriteFileAtomic(s.path, append(data, '\n'), 0600)
+	})
+}
+
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func unseal(key, sealed, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("invalid encrypted value")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,189 @@
+package secrets
+
+import (
+	"bytes"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newKey(t *testing.T) []byte {
+	t.Helper()
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// newStore returns an unlocked store saved in a temporary directory
+func newStore(t *testing.T) *Store {
+	t.Helper()
+	key := newKey(t)
+	check, err := seal(key, []byte(checkValue), []byte("check"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Store{
+		path: filepath.Join(t.TempDir(), fileName),
+		file: storeFile{Version: 1, KDF: kdfKeyFile, Check: check},
+		key:  key,
+	}
+}
+
+func TestSeal(t *testing.T) {
+	key := newKey(t)
+	for _, value := range []string{"", "secret", "  spaced value  ", strings.Repeat("x", 4096)} {
+		sealed, err := seal(key, []byte(value), []byte("p/env/NAME"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(value) > 0 && bytes.Contains(sealed, []byte(value)) {
+			t.Errorf("the value '%s' is sealed in clear", value)
+		}
+		opened, err := unseal(key, sealed, []byte("p/env/NAME"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(opened) != value {
+			t.Errorf("got '%s', want '%s'", opened, value)
+		}
+		if _, err := unseal(newKey(t), sealed, []byte("p/env/NAME")); err == nil {
+			t.Errorf("another key unsealed '%s'", value)
+		}
+	}
+	if _, err := unseal(key, []byte("short"), nil); err == nil {
+		t.Error("unsealed a value shorter than the nonce")
+	}
+}
+
+func TestSwappedValues(t *testing.T) {
+	s := newStore(t)
+	original := Secret{Project: "1", Env: "api", Name: "TOKEN"}
+	sealed, err := seal(s.key, []byte("value"), original.additionalData())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]Secret{
+		"another project":     {Project: "2", Env: "api", Name: "TOKEN"},
+		"another environment": {Project: "1", Env: "web", Name: "TOKEN"},
+		"the project":         {Project: "1", Name: "TOKEN"},
+		"another name":        {Project: "1", Env: "api", Name: "PASSWORD"},
+	}
+	for name, secret := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret.Value = sealed
+			if value, err := s.decrypt(secret); err == nil {
+				t.Errorf("decrypted '%s' moved to %s/%s/%s", value, secret.Project, secret.Env, secret.Name)
+			}
+		})
+	}
+
+	original.Value = sealed
+	if value, err := s.decrypt(original); err != nil || value != "value" {
+		t.Errorf("got '%s' and error %v, want value", value, err)
+	}
+}
+
+func TestUseKey(t *testing.T) {
+	passphraseStore := storeFile{KDF: kdfArgon2id, Salt: []byte("0123456789abcdef"), Time: 1, Memory: 64, Threads: 1}
+	keyFileStore := storeFile{KDF: kdfKeyFile}
+	key := newKey(t)
+
+	tests := []struct {
+		name  string
+		file  storeFile
+		key   []byte
+		using []byte
+		err   string
+	}{
+		{"passphrase", passphraseStore, passphraseStore.derive("right"), passphraseStore.derive("right"), ""},
+		{"wrong passphrase", passphraseStore, passphraseStore.derive("right"), passphraseStore.derive("wrong"), "wrong passphrase"},
+		{"key file", keyFileStore, key, key, ""},
+		{"wrong key file", keyFileStore, key, newKey(t), "not the one of the secrets store"},
+		{"truncated key", keyFileStore, key, key[:16], "not the one of the secrets store"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Store{file: tt.file}
+			var err error
+			if s.file.Check, err = seal(tt.key, []byte(checkValue), []byte("check")); err != nil {
+				t.Fatal(err)
+			}
+			err = s.useKey(tt.using)
+			switch {
+			case tt.err == "" && err != nil:
+				t.Errorf("got error %v", err)
+			case tt.err == "" && !bytes.Equal(s.key, tt.using):
+				t.Error("the store is not unlocked")
+			case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Errorf("got error %v, want one containing '%s'", err, tt.err)
+			case tt.err != "" && s.key != nil:
+				t.Error("the store is unlocked")
+			}
+		})
+	}
+}
+
+func TestReadKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+	if _, err := readKeyFile(path, false); err == nil {
+		t.Error("read a missing key file")
+	}
+	created, err := readKeyFile(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm()&0077 != 0 {
+		t.Errorf("the key file is readable by others or missing: %v", err)
+	}
+	read, err := readKeyFile(path, true)
+	if err != nil || !bytes.Equal(read, created) {
+		t.Errorf("got another key reading the key file again: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("not a key\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readKeyFile(path, false); err == nil {
+		t.Error("read an invalid key file")
+	}
+}
+
+func TestMoveScope(t *testing.T) {
+	s := newStore(t)
+	for _, secret := range []Secret{
+		{Project: "1", Env: "api", Name: "TOKEN"},
+		{Project: "1", Env: "api", Name: "PASSWORD"},
+		{Project: "1", Name: "SHARED"},
+		{Project: "2", Env: "web", Name: "TOKEN"},
+	} {
+		if err := s.Set(secret.Project, secret.Env, secret.Name, secret.Project+"/"+secret.Env+"/"+secret.Name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	moved, err := s.MoveScope("1", "api", "2", "web")
+	if err != nil || moved != 2 {
+		t.Fatalf("moved %d secrets with error %v, want 2", moved, err)
+	}
+	if n := len(s.List("1", "api")); n != 0 {
+		t.Errorf("%d secrets left in the old scope", n)
+	}
+	for _, name := range []string{"TOKEN", "PASSWORD"} {
+		if value, err := s.Get("2", "web", name); err != nil || value != "1/api/"+name {
+			t.Errorf("got '%s' and error %v for %s, want the moved value", value, err, name)
+		}
+	}
+
+	removed, err := s.RemoveScope("1", "")
+	if err != nil || removed != 1 {
+		t.Errorf("removed %d secrets with error %v, want 1", removed, err)
+	}
+	if n := len(s.List("", "")); n != 2 {
+		t.Errorf("got %d secrets, want 2", n)
+	}
+}
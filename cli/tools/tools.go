@@ -0,0 +1,129 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leodahal4/dev-kit/config"
+)
+
+// versionTimeout bounds the version command of a tool
+const versionTimeout = 10 * time.Second
+
+// Statuses of a checked tool
+const (
+	StatusOK       = "ok"
+	StatusMissing  = "missing"
+	StatusOutdated = "outdated"
+	StatusUnknown  = "unknown"
+)
+
+// Result is the outcome of checking a tool
+type Result struct {
+	Tool    config.ToolRequirement
+	Status  string
+	Path    string
+	Version string
+	Err     error
+}
+
+// Passed reports whether the tool can be used
+func (r Result) Passed() bool {
+	return r.Status == StatusOK
+}
+
+var versionPattern = regexp.MustCompile(`\d+\.\d+(\.\d+)?`)
+
+// Check resolves a tool on PATH, runs its version command and compares the
+// version it prints with the minimum of the tool
+func Check(ctx context.Context, tool config.ToolRequirement) Result {
+	result := Result{Tool: tool}
+
+	path, err := exec.LookPath(tool.Executable())
+	if err != nil {
+		result.Status, result.Err = StatusMissing, fmt.Errorf("%s not found on PATH", tool.Executable())
+		return result
+	}
+	result.Path = path
+
+	ctx, cancel := context.WithTimeout(ctx, versionTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, path, tool.VersionArgs...).CombinedOutput()
+	if err != nil {
+		result.Status, result.Err = StatusUnknown, fmt.Errorf("'%s' failed: %v", strings.TrimSpace(tool.Executable()+" "+strings.Join(tool.VersionArgs, " ")), err)
+		return result
+	}
+
+	result.Version = versionPattern.FindString(string(output))
+	if result.Version == "" {
+		result.Status, result.Err = StatusUnknown, errors.New("could not read its version")
+		return result
+	}
+	if tool.MinVersion != "" && CompareVersions(result.Version, tool.MinVersion) < 0 {
+		result.Status, result.Err = StatusOutdated, fmt.Errorf("version %s is older than %s", result.Version, tool.MinVersion)
+		return result
+	}
+	result.Status = StatusOK
+	return result
+}
+
+// CheckAll checks every tool concurrently, done is called as soon as a tool
+// has been checked
+func CheckAll(ctx context.Context, tools []config.ToolRequirement, done func(Result)) []Result {
+	results := make([]Result, len(tools))
+	var wg sync.WaitGroup
+	for i, tool := range tools {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = Check(ctx, tool)
+			if done != nil {
+				done(results[i])
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// Failed returns the required tools which did not pass
+func Failed(results []Result) []Result {
+	var failed []Result
+	for _, r := range results {
+		if r.Tool.Required && !r.Passed() {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+// CompareVersions compares two dotted versions numerically, missing parts are
+// zero so that 1.21 equals 1.21.0
+func CompareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < max(len(as), len(bs)); i++ {
+		x, y := versionPart(as, i), versionPart(bs, i)
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionPart(parts []string, i int) int {
+	if i >= len(parts) {
+		return 0
+	}
+	n, _ := strconv.Atoi(strings.TrimLeft(parts[i], "v"))
+	return n
+}
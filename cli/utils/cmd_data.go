@@ -1,15 +1,29 @@
 package utils
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/leodahal4/dev-kit/config"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
-func ParseAndSaveCommand(cmd *cobra.Command, _ []string) {
+func ParseAndSaveCommand(cmd *cobra.Command, args []string) {
+	_, configOnly := cmd.Annotations[AnnotationSkipToolCheck]
 	err := config.ValidateConfig(config.GetConfig())
 	if err != nil {
-		logrus.Fatal(err)
+		// the commands managing the configuration are the ones fixing it
+		if !configOnly {
+			logrus.Fatal(err)
+		}
+		logrus.Warn(err)
+	}
+	warnProjectProblems(args)
+	if !configOnly {
+		if err := checkProjectTools(args); err != nil {
+			logrus.Fatal(err)
+		}
 	}
 	switch cmd.Use{
 	case CMD_INIT:
@@ -18,7 +32,59 @@ func ParseAndSaveCommand(cmd *cobra.Command, _ []string) {
 	}
 }
 
+// warnProjectProblems warns about the projects which cannot be run, except the
+// one referenced by the first argument: the commands running it report its
+// problems as errors
+func warnProjectProblems(args []string) {
+	cfg := config.GetConfig()
+	var target *config.ProjectConfig
+	if len(args) > 0 {
+		ref, _, _ := strings.Cut(args[0], "/")
+		target, _ = cfg.FindProject(ref)
+	}
+	for i := range cfg.Projects {
+		if &cfg.Projects[i] == target {
+			continue
+		}
+		if err := cfg.Projects[i].Validate(); err != nil {
+			logrus.Warn(err)
+		}
+	}
+}
+
+// checkProjectTools fails when the first argument references a project whose
+// tools have not passed "devkit init check"
+func checkProjectTools(args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	ref, _, _ := strings.Cut(args[0], "/")
+	project, err := config.GetConfig().FindProject(ref)
+	if err != nil {
+		// not a project, the command reports what is wrong with its arguments
+		return nil
+	}
+
+	project.RefreshTools()
+	if len(project.Tools) == 0 || project.ToolsChecked {
+		return nil
+	}
+	return fmt.Errorf("project '%s' needs tools which have not been checked (%s), run \"devkit init check --project %s\"",
+		project.Name, strings.Join(project.ToolNames(), ", "), project.Name)
+}
+
 
 const (
 	CMD_INIT = "init"
 )
+
+// AnnotationSkipToolCheck marks commands which only manage the configuration,
+// and work on projects whose tools have not been checked or on an invalid
+// configuration, only warning about it
+const AnnotationSkipToolCheck = "devkit/skip-tool-check"
+
+// ConfigOnly are the annotations of the commands only managing the
+// configuration
+func ConfigOnly() map[string]string {
+	return map[string]string{AnnotationSkipToolCheck: "true"}
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ParseCommandLine splits the command running an environment into its
+// executable and arguments, unless it is run through the shell. An empty
+// command keeps the default derived from the environment language.
+func ParseCommandLine(line string, shell bool) (string, []string, bool) {
+	if line == "" {
+		return "", nil, false
+	}
+	if shell {
+		return line, nil, true
+	}
+	fields := SplitCommandLine(line)
+	return fields[0], fields[1:], false
+}
+
+// SplitCommandLine splits a command line on whitespace, keeping single or
+// double quoted sections together
+func SplitCommandLine(line string) []string {
+	var fields []string
+	var current strings.Builder
+	var quote rune
+	inField := false
+
+	for _, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inField = true
+		case unicode.IsSpace(r):
+			if inField {
+				fields = append(fields, current.String())
+				current.Reset()
+				inField = false
+			}
+		default:
+			current.WriteRune(r)
+			inField = true
+		}
+	}
+	if inField {
+		fields = append(fields, current.String())
+	}
+	return fields
+}
+
+// JoinCommandLine joins a command and its arguments into a line which
+// SplitCommandLine splits back, quoting the arguments which need it
+func JoinCommandLine(command string, args []string) string {
+	fields := []string{command}
+	for _, arg := range args {
+		switch {
+		case strings.ContainsRune(arg, '"'):
+			fields = append(fields, "'"+arg+"'")
+		case arg == "" || strings.ContainsAny(arg, " \t'"):
+			fields = append(fields, `"`+arg+`"`)
+		default:
+			fields = append(fields, arg)
+		}
+	}
+	return strings.Join(fields, " ")
+}
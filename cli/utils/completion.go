@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/leodahal4/dev-kit/config"
+	"github.com/spf13/cobra"
+)
+
+// CompleteProjects completes the first argument of commands taking a project
+// ID or name
+func CompleteProjects(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg := config.GetConfig()
+	if cfg == nil || len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+	for _, project := range cfg.Projects {
+		if strings.HasPrefix(project.Name, toComplete) {
+			completions = append(completions, completion(project.Name, project.Description))
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// CompleteEnvironments completes the first argument of commands taking a
+// "<project>/<env>" reference
+func CompleteEnvironments(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg := config.GetConfig()
+	if cfg == nil || len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+	for _, project := range cfg.Projects {
+		for _, env := range project.Environments {
+			ref := project.Name + "/" + env.Name
+			if strings.HasPrefix(ref, toComplete) {
+				completions = append(completions, completion(ref, env.Description))
+			}
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// CompleteTargets completes the first argument of commands taking a
+// "<project>[/<env>]" reference, project names as well as environments
+func CompleteTargets(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	projects, directive := CompleteProjects(cmd, args, toComplete)
+	envs, _ := CompleteEnvironments(cmd, args, toComplete)
+	return append(projects, envs...), directive
+}
+
+func completion(value, description string) string {
+	description = strings.TrimSpace(description)
+	if description == "" {
+		return value
+	}
+	return fmt.Sprintf("%s\t%s", value, description)
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// Output formats of the commands printing configuration
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+)
+
+// AddOutputFlag adds the --output flag selecting between table and JSON output
+func AddOutputFlag(cmd *cobra.Command) {
+	cmd.Flags().StringP("output", "o", OutputTable, "output format, table or json")
+	_ = cmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions([]string{OutputTable, OutputJSON}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// OutputFormat returns the validated value of the --output flag
+func OutputFormat(cmd *cobra.Command) (string, error) {
+	format, _ := cmd.Flags().GetString("output")
+	switch format {
+	case OutputTable, OutputJSON:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown output format '%s', use %s or %s", format, OutputTable, OutputJSON)
+}
+
+// PrintJSON prints v as indented JSON
+func PrintJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// NewTable returns a writer aligning tab separated columns
+func NewTable(w io.Writer) *tabwriter.Writer {
+	return tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+}
+
+// Cell returns value for a table cell, "-" when it is empty
+func Cell(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// AddConfirmFlag adds the --yes flag skipping the confirmation of destructive
+// commands
+func AddConfirmFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolP("yes", "y", false, "do not ask for confirmation")
+}
+
+// Confirm asks a yes/no question unless --yes was given. Without a terminal to
+// ask on, --yes is required.
+func Confirm(cmd *cobra.Command, question string) (bool, error) {
+	if yes, _ := cmd.Flags().GetBool("yes"); yes {
+		return true, nil
+	}
+	if !NewPrompter(cmd).Interactive() {
+		return false, fmt.Errorf("%s Confirm with --yes when not running in a terminal", question)
+	}
+	answer := strings.ToLower(AskInput(question+" (yes/no): ", ""))
+	return answer == "yes" || answer == "y", nil
+}
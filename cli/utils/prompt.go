@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/leodahal4/dev-kit/cli/form"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// AddNonInteractiveFlags adds the flags disabling the prompts of a command
+func AddNonInteractiveFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolP("yes", "y", false, "accept the defaults and fail instead of prompting for missing values")
+	cmd.Flags().Bool("non-interactive", false, "same as --yes")
+}
+
+// Prompter reads the values of a command from its flags, and asks for the
+// ones which were not given in a form unless prompting is disabled. Values are
+// declared first, and can be read once Run returned.
+type Prompter struct {
+	cmd         *cobra.Command
+	interactive bool
+	form        *form.Form
+	checks      []func() error
+}
+
+// NewPrompter returns the prompter of cmd, which never prompts when --yes or
+// --non-interactive is given or stdin is not a terminal
+func NewPrompter(cmd *cobra.Command) *Prompter {
+	yes, _ := cmd.Flags().GetBool("yes")
+	nonInteractive, _ := cmd.Flags().GetBool("non-interactive")
+	return &Prompter{
+		cmd:         cmd,
+		interactive: !yes && !nonInteractive && term.IsTerminal(int(os.Stdin.Fd())),
+	}
+}
+
+// Interactive reports whether missing values are asked for
+func (p *Prompter) Interactive() bool {
+	return p.interactive
+}
+
+// asks reports whether the value of flag is asked for in the form
+func (p *Prompter) asks(flag string) bool {
+	return p.interactive && !p.cmd.Flags().Changed(flag)
+}
+
+func (p *Prompter) getForm() *form.Form {
+	if p.form == nil {
+		p.form = form.New("")
+	}
+	return p.form
+}
+
+// flagString returns the value of a flag, or suggested when it was not given,
+// and records the validation of the value for Run
+func (p *Prompter) flagString(flag, suggested string, validate func(string) error) func() string {
+	value := suggested
+	if p.cmd.Flags().Changed(flag) {
+		value, _ = p.cmd.Flags().GetString(flag)
+		value = strings.TrimSpace(value)
+	}
+	if validate != nil {
+		p.checks = append(p.checks, func() error {
+			err := validate(value)
+			switch {
+			case err == nil:
+				return nil
+			case value == "" && !p.cmd.Flags().Changed(flag):
+				return fmt.Errorf("--%s is required when not prompting", flag)
+			default:
+				return fmt.Errorf("--%s: %v", flag, err)
+			}
+		})
+	}
+	return func() string { return value }
+}
+
+// Text declares a text value read from a string flag, suggested is pre-filled
+// in the form and used as is without prompts. validate may be nil.
+func (p *Prompter) Text(flag, label, suggested string, validate func(string) error) func() string {
+	if !p.asks(flag) {
+		return p.flagString(flag, suggested, validate)
+	}
+	return p.getForm().Text(label, suggested, validate).Value
+}
+
+// Dir declares a directory value read from a string flag, which can be browsed
+// in the form
+func (p *Prompter) Dir(flag, label, suggested string, validate func(string) error) func() string {
+	if !p.asks(flag) {
+		return p.flagString(flag, suggested, validate)
+	}
+	return p.getForm().Dir(label, suggested, validate).Value
+}
+
+// Select declares a choice among options read from a string flag, resolve
+// turns the value of the flag into the value of an option
+func (p *Prompter) Select(flag, label string, options []form.Option, selected string, resolve func(string) (string, error)) func() string {
+	if p.asks(flag) {
+		return p.getForm().Select(label, options, selected).Value
+	}
+
+	value := selected
+	if p.cmd.Flags().Changed(flag) {
+		value, _ = p.cmd.Flags().GetString(flag)
+	}
+	// resolved right away, the validations of other values may depend on it
+	resolved, err := resolve(value)
+	p.checks = append(p.checks, func() error {
+		if value == "" {
+			return fmt.Errorf("--%s is required when not prompting", flag)
+		}
+		return err
+	})
+	return func() string { return resolved }
+}
+
+// Bool declares a yes/no value read from a boolean flag
+func (p *Prompter) Bool(flag, label string, suggested bool) func() bool {
+	if p.asks(flag) {
+		return p.getForm().Toggle(label, suggested).Value
+	}
+	value := suggested
+	if p.cmd.Flags().Changed(flag) {
+		value, _ = p.cmd.Flags().GetBool(flag)
+	}
+	return func() bool { return value }
+}
+
+// Run shows the form of the values declared since the last run, if any, and
+// then validates the values read from flags, as they may depend on the
+// answers
+func (p *Prompter) Run(title string) error {
+	checks, f := p.checks, p.form
+	p.checks, p.form = nil, nil
+
+	if f != nil {
+		f.SetTitle(title)
+		if err := f.Run(); err != nil {
+			return err
+		}
+	}
+	for _, check := range checks {
+		if err := check(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package workspace
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/leodahal4/dev-kit/cli/git"
+	"github.com/leodahal4/dev-kit/cli/utils"
+	"github.com/leodahal4/dev-kit/config"
+	"github.com/spf13/cobra"
+)
+
+func NewStatusCommand() *cobra.Command {
+	statusCmd := &cobra.Command{
+		Use:   "status <project>[/<env>]",
+		Short: "Show the git status of the environments of a project",
+		Long: `Show the branch, the commits ahead and behind the upstream branch, the changed
+files and the last commit of the repository of every environment of a project.
+The upstream branches are the ones of the last fetch, "devkit sync --fetch-only"
+updates them.`,
+		Example:           "  devkit status shop",
+		Args:              cobra.ExactArgs(1),
+		Annotations:       utils.ConfigOnly(),
+		ValidArgsFunction: utils.CompleteTargets,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			utils.ParseAndSaveCommand(cmd, args)
+		},
+		RunE: Status,
+	}
+
+	addJobsFlag(statusCmd)
+	utils.AddOutputFlag(statusCmd)
+
+	return statusCmd
+}
+
+// envStatus is the status of the repository of an environment
+type envStatus struct {
+	Env    string      `json:"environment"`
+	Path   string      `json:"path"`
+	Status *git.Status `json:"status,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// Status prints the git status of the environments referenced by the first
+// argument
+func Status(cmd *cobra.Command, args []string) error {
+	format, err := utils.OutputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	jobs, err := jobsFlag(cmd)
+	if err != nil {
+		return err
+	}
+	if err := git.Available(); err != nil {
+		return err
+	}
+	_, envs, err := config.GetConfig().FindTargets(args[0])
+	if err != nil {
+		return err
+	}
+
+	statuses := make([]envStatus, len(envs))
+	forEach(len(envs), jobs, func(i int) {
+		statuses[i] = envStatus{Env: envs[i].Name, Path: envs[i].Path}
+		status, err := git.GetStatus(cmd.Context(), envs[i].Path)
+		if err != nil {
+			statuses[i].Error = err.Error()
+			return
+		}
+		statuses[i].Status = &status
+	})
+	if format == utils.OutputJSON {
+		return utils.PrintJSON(cmd.OutOrStdout(), statuses)
+	}
+
+	w := utils.NewTable(cmd.OutOrStdout())
+	fmt.Fprintln(w, "ENV\tBRANCH\tAHEAD\tBEHIND\tCHANGES\tLAST COMMIT")
+	for _, s := range statuses {
+		if s.Status == nil {
+			fmt.Fprintf(w, "%s\t-\t-\t-\t-\t%s\n", s.Env, notRepository(s.Error))
+			continue
+		}
+		ahead, behind := "-", "-"
+		if s.Status.Upstream != "" {
+			ahead, behind = fmt.Sprint(s.Status.Ahead), fmt.Sprint(s.Status.Behind)
+		}
+		changes := "clean"
+		if s.Status.Dirty() {
+			changes = fmt.Sprintf("%d changed", s.Status.Changed)
+		}
+		commit := "-"
+		if c := s.Status.Commit; c.Hash != "" {
+			commit = fmt.Sprintf("%s %s (%s)", c.Hash, truncate(c.Subject, 50), c.When)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", s.Env, s.Status.Branch, ahead, behind, changes, commit)
+	}
+	return w.Flush()
+}
+
+// notRepository shortens the error of git in a directory which is not a
+// repository
+func notRepository(err string) string {
+	if strings.Contains(err, "not a git repository") {
+		return "not a git repository"
+	}
+	return err
+}
+
+func truncate(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n-1]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,182 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/leodahal4/dev-kit/cli/git"
+	"github.com/leodahal4/dev-kit/cli/utils"
+	"github.com/leodahal4/dev-kit/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func NewSyncCommand() *cobra.Command {
+	syncCmd := &cobra.Command{
+		Use:   "sync <project>[/<env>]",
+		Short: "Fetch and pull the repositories of the environments of a project",
+		Long: `Fetch the repository of every environment of a project, several at once, and
+fast-forward the branches checked out to their upstream branch. Repositories
+with local changes, or whose branch diverged from its upstream branch, are
+only fetched, to be merged or rebased by hand. Environments sharing a
+repository are synced once.`,
+		Example:           "  devkit sync shop\n  devkit sync shop --fetch-only --jobs 2",
+		Args:              cobra.ExactArgs(1),
+		Annotations:       utils.ConfigOnly(),
+		ValidArgsFunction: utils.CompleteTargets,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			utils.ParseAndSaveCommand(cmd, args)
+		},
+		RunE: Sync,
+	}
+
+	syncCmd.Flags().Bool("fetch-only", false, "fetch without updating the branches checked out")
+	addJobsFlag(syncCmd)
+	utils.AddOutputFlag(syncCmd)
+
+	return syncCmd
+}
+
+// syncResult is the outcome of the sync of a repository
+type syncResult struct {
+	Envs   []string `json:"environments"`
+	Path   string   `json:"path"`
+	Branch string   `json:"branch,omitempty"`
+
+	// Pulled counts the commits the branch was fast-forwarded by
+	Pulled int    `json:"pulled"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Sync fetches and pulls the repositories of the environments referenced by
+// the first argument
+func Sync(cmd *cobra.Command, args []string) error {
+	format, err := utils.OutputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	jobs, err := jobsFlag(cmd)
+	if err != nil {
+		return err
+	}
+	fetchOnly, _ := cmd.Flags().GetBool("fetch-only")
+	if err := git.Available(); err != nil {
+		return err
+	}
+	_, envs, err := config.GetConfig().FindTargets(args[0])
+	if err != nil {
+		return err
+	}
+
+	repos := repositories(cmd.Context(), envs, jobs)
+	logrus.Infof("Syncing %s, %d at once", plural(len(repos), "repository", "repositories"), min(jobs, len(repos)))
+	results := make([]syncResult, len(repos))
+	forEach(len(repos), jobs, func(i int) {
+		repo := repos[i]
+		results[i] = syncResult{Envs: repo.Envs, Path: repo.Root}
+		if repo.Err != nil {
+			results[i].Result, results[i].Error = "skipped", repo.Err.Error()
+			return
+		}
+		if err := syncRepository(cmd.Context(), &results[i], fetchOnly); err != nil {
+			results[i].Result, results[i].Error = "failed", err.Error()
+		}
+		logrus.Debugf("%s: %s", repo.Root, results[i].Result)
+	})
+
+	failed := 0
+	for _, r := range results {
+		if r.Result == "failed" {
+			failed++
+		}
+	}
+	if format == utils.OutputJSON {
+		if err := utils.PrintJSON(cmd.OutOrStdout(), results); err != nil {
+			return err
+		}
+	} else {
+		w := utils.NewTable(cmd.OutOrStdout())
+		fmt.Fprintln(w, "ENV\tBRANCH\tRESULT")
+		for _, r := range results {
+			result := r.Result
+			if r.Error != "" {
+				result += ": " + notRepository(r.Error)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", strings.Join(r.Envs, ", "), utils.Cell(r.Branch), result)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d repositories failed to sync", failed, len(results))
+	}
+	return nil
+}
+
+// syncRepository fetches the repository of result and fast-forwards its branch
+// when it is safe to do so
+func syncRepository(ctx context.Context, result *syncResult, fetchOnly bool) error {
+	remotes, err := git.Output(ctx, result.Path, "remote")
+	if err != nil {
+		return err
+	}
+	if remotes == "" {
+		result.Result = "skipped, no remote"
+		return nil
+	}
+	if _, err := git.Output(ctx, result.Path, "fetch", "--all", "--prune", "--quiet"); err != nil {
+		return err
+	}
+
+	status, err := git.GetStatus(ctx, result.Path)
+	if err != nil {
+		return err
+	}
+	result.Branch = status.Branch
+	switch {
+	case fetchOnly:
+		result.Result = "fetched" + behind(status.Behind)
+		return nil
+	case status.Upstream == "":
+		result.Result = "fetched, no upstream branch"
+		return nil
+	case status.Behind == 0:
+		result.Result = "up to date"
+		if status.Ahead > 0 {
+			result.Result += ", " + plural(status.Ahead, "commit", "commits") + " to push"
+		}
+		return nil
+	case status.Dirty():
+		result.Result = fmt.Sprintf("fetched%s, not pulled as %s changed", behind(status.Behind), plural(status.Changed, "file", "files"))
+		return nil
+	case status.Ahead > 0:
+		result.Result = fmt.Sprintf("fetched%s, not pulled as the branch diverged from %s", behind(status.Behind), status.Upstream)
+		return nil
+	}
+
+	if _, err := git.Output(ctx, result.Path, "merge", "--ff-only", "--quiet", "@{upstream}"); err != nil {
+		return err
+	}
+	result.Pulled = status.Behind
+	result.Result = "pulled " + plural(status.Behind, "commit", "commits")
+	return nil
+}
+
+// behind describes the commits not pulled yet
+func behind(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return ", " + plural(n, "commit", "commits") + " behind"
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+	return strconv.Itoa(n) + " " + many
+}
@@ -0,0 +1,84 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/leodahal4/dev-kit/cli/git"
+	"github.com/leodahal4/dev-kit/config"
+	"github.com/spf13/cobra"
+)
+
+// defaultJobs bounds the git commands run at once, which mostly wait on the
+// network
+const defaultJobs = 8
+
+// repository is a git repository holding the paths of one or more
+// environments, a monorepo holding several of them
+type repository struct {
+	Root string
+	Envs []string
+
+	// Err tells why the environment is not in a repository
+	Err error
+}
+
+// repositories groups the environments by the repository holding their path,
+// in the order of the environments
+func repositories(ctx context.Context, envs []config.EnvironmentConfig, jobs int) []*repository {
+	roots := make([]string, len(envs))
+	errs := make([]error, len(envs))
+	forEach(len(envs), jobs, func(i int) {
+		roots[i], errs[i] = git.TopLevel(ctx, envs[i].Path)
+	})
+
+	var repos []*repository
+	byRoot := map[string]*repository{}
+	for i, env := range envs {
+		if errs[i] != nil {
+			repos = append(repos, &repository{Root: env.Path, Envs: []string{env.Name}, Err: fmt.Errorf("not a git repository")})
+			continue
+		}
+		if repo, ok := byRoot[roots[i]]; ok {
+			repo.Envs = append(repo.Envs, env.Name)
+			continue
+		}
+		byRoot[roots[i]] = &repository{Root: roots[i], Envs: []string{env.Name}}
+		repos = append(repos, byRoot[roots[i]])
+	}
+	return repos
+}
+
+// forEach calls fn with every index below n, running at most jobs calls at once
+func forEach(n, jobs int, fn func(i int)) {
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(max(jobs, 1), n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// addJobsFlag adds the --jobs flag bounding the repositories handled at once
+func addJobsFlag(cmd *cobra.Command) {
+	cmd.Flags().IntP("jobs", "j", defaultJobs, "number of repositories handled at once")
+}
+
+func jobsFlag(cmd *cobra.Command) (int, error) {
+	jobs, _ := cmd.Flags().GetInt("jobs")
+	if jobs < 1 {
+		return 0, fmt.Errorf("--jobs has to be at least 1")
+	}
+	return jobs, nil
+}
@@ -1,16 +1,18 @@
 package config
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mitchellh/go-homedir"
 	"github.com/sirupsen/logrus"
-	"gopkg.in/yaml.v3"
 )
 
 var globalConfig *GlobalConfig
@@ -23,6 +25,7 @@ const (
 
 // Default configuration values
 var defaultConfig = GlobalConfig{
+	VERSION:            CurrentVersion,
 	DEBUG:              false,
 	PPROF_ENABLED:      false,
 	PPROF_ADD_AND_PORT: "localhost:6060",
@@ -37,9 +40,21 @@ type ProjectConfig struct {
 	ID             string              `json:"id"`
 	Name           string              `json:"name"`
 	Description    string              `json:"description"`
-	IsValid        bool                `json:"-"`
-	IsMicroservice bool                `json:"is_microservice"`
+	IsValid        bool                `json:"-" yaml:"-"`
+	IsMicroservice bool                `json:"is_microservice" yaml:"is_microservice"`
 	Environments   []EnvironmentConfig `json:"environments"`
+
+	// Env holds variables shared by every environment of the project
+	Env map[string]string `json:"env"`
+
+	// Tools lists the tools needed by the environments of the project, see
+	// RefreshTools, and ToolsChecked whether "devkit init check" found them
+	Tools        []ToolRequirement `json:"tools"`
+	ToolsChecked bool              `json:"tools_checked" yaml:"tools_checked"`
+
+	// Tasks are shared by every environment of the project, which can
+	// override them with tasks of the same name
+	Tasks map[string]TaskConfig `json:"tasks,omitempty" yaml:"tasks,omitempty"`
 }
 
 type EnvironmentConfig struct {
@@ -47,65 +62,182 @@ type EnvironmentConfig struct {
 	Description string `json:"description"`
 	Language    string `json:"language"`
 	Path        string `json:"path"`
+
+	// Repository is the git repository Path was cloned from by "devkit
+	// project clone", and Branch the branch checked out, empty for the default
+	// one
+	Repository string `json:"repository,omitempty" yaml:"repository,omitempty"`
+	Branch     string `json:"branch,omitempty" yaml:"branch,omitempty"`
+
+	// Command is the executable started by "devkit run". When it is empty a
+	// default derived from Language is used instead.
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+
+	// Shell runs Command (joined with Args) through the system shell, which
+	// allows pipes, redirects and variable expansion.
+	Shell bool `json:"shell"`
+
+	// Restart is the restart policy applied when the process exits, one of
+	// never (default), on-failure or always.
+	Restart string `json:"restart"`
+
+	// MaxRestarts limits how many times the process is restarted, 0 means
+	// there is no limit other than the crash loop detection.
+	MaxRestarts int `json:"max_restarts" yaml:"max_restarts"`
+
+	// Watch selects the files which restart the environment in --watch mode
+	Watch WatchConfig `json:"watch"`
+
+	// DependsOn lists the environments of the same project which have to be
+	// ready before this one is started
+	DependsOn []string `json:"depends_on" yaml:"depends_on"`
+
+	// Ready decides when the environment is ready for its dependents, without
+	// a probe it is ready as soon as its process started
+	Ready ReadinessProbe `json:"ready"`
+
+	// Env and EnvFiles set variables for the process on top of the ones of the
+	// project. EnvFiles are .env files, relative to Path, loaded in order, and
+	// Env takes precedence over them.
+	Env      map[string]string `json:"env"`
+	EnvFiles []string          `json:"env_files" yaml:"env_files"`
+
+	// Build and Test are the command lines building and testing the
+	// environment, run through the shell in Path
+	Build string `json:"build"`
+	Test  string `json:"test"`
+
+	// Tasks are the named steps run by "devkit task", such as lint or
+	// migrate. Build and Test are the build and test tasks unless Tasks
+	// declares them.
+	Tasks map[string]TaskConfig `json:"tasks,omitempty" yaml:"tasks,omitempty"`
+}
+
+// WatchConfig holds the globs, relative to the environment path, used by
+// --watch. Files ignored by .gitignore are never watched, and when Include is
+// empty every other file is. Globs support "**" to match any number of
+// directories.
+type WatchConfig struct {
+	Include []string `json:"include"`
+	Exclude []string `json:"exclude"`
 }
 
+// ReadinessProbe checks that an environment is ready. Every check which is set
+// has to pass; they are retried every Interval until Timeout expires.
+type ReadinessProbe struct {
+	// TCP is an address which has to accept connections, e.g. localhost:5432
+	TCP string `json:"tcp"`
+
+	// HTTP is a URL which has to answer with a 2xx status
+	HTTP string `json:"http"`
+
+	// Command is run through the shell in the environment path and has to exit 0
+	Command string `json:"command"`
+
+	// Timeout and Interval are durations such as "30s", they default to 60s and 1s
+	Timeout  string `json:"timeout"`
+	Interval string `json:"interval"`
+}
+
+// Default durations of a readiness probe
+const (
+	DefaultProbeTimeout  = time.Minute
+	DefaultProbeInterval = time.Second
+)
+
+// IsSet reports whether the probe checks anything
+func (p ReadinessProbe) IsSet() bool {
+	return p.TCP != "" || p.HTTP != "" || p.Command != ""
+}
+
+// Durations returns the parsed timeout and interval of the probe, using the
+// defaults for empty values
+func (p ReadinessProbe) Durations() (time.Duration, time.Duration, error) {
+	timeout, interval := DefaultProbeTimeout, DefaultProbeInterval
+	var err error
+	if p.Timeout != "" {
+		if timeout, err = time.ParseDuration(p.Timeout); err != nil || timeout <= 0 {
+			return 0, 0, fmt.Errorf("invalid readiness timeout '%s'", p.Timeout)
+		}
+	}
+	if p.Interval != "" {
+		if interval, err = time.ParseDuration(p.Interval); err != nil || interval <= 0 {
+			return 0, 0, fmt.Errorf("invalid readiness interval '%s'", p.Interval)
+		}
+	}
+	return timeout, interval, nil
+}
+
+// Restart policies of an environment
+const (
+	RestartNever     = "never"
+	RestartOnFailure = "on-failure"
+	RestartAlways    = "always"
+)
+
 type GlobalConfig struct {
+	// VERSION is the version of the schema of the configuration, see
+	// CurrentVersion
+	VERSION int `json:"version" yaml:"version"`
+
 	// DEBUG is a boolean value that determines whether the application is in debug mode.
 	DEBUG bool `json:"debug" default:"false" required:"false"`
 
 	// PPROF_ENABLED is a boolean value that determines whether the pprof server is enabled.
-	PPROF_ENABLED bool `json:"PPROF_ENABLED" default:"false" required:"false"`
+	PPROF_ENABLED bool `json:"pprof_enabled" yaml:"pprof_enabled" default:"false" required:"false"`
 
-	// PPROF_PORT is the address and port for the pprof server.
-	PPROF_ADD_AND_PORT string `json:"PPROF_PORT" default:"localhost:6060" required:"false"`
+	// PPROF_ADD_AND_PORT is the address and port for the pprof server.
+	PPROF_ADD_AND_PORT string `json:"pprof_address" yaml:"pprof_address" default:"localhost:6060" required:"false"`
 
 	// LOG_FORMAT is the format of the logs.
-	LOG_FORMAT string `json:"LOG_FORMAT" default:"text" required:"false"`
+	LOG_FORMAT string `json:"log_format" yaml:"log_format" default:"text" required:"false"`
 
 	// KUBECONFIG is the path to the kubeconfig file.
 	// NOTE: THIS IS ONLY USED IF API DOESNOT PROVIDE KUBECONFIG
-	KUBECONFIG  string `json:"KUBECONFIG" required:"false"`
-	SQLITEDB    string `json:"db_path" default:".dev-kit/devkit.sqlite3"`
-	HOME_FOLDER string `json:"home"`
+	KUBECONFIG string `json:"kubeconfig" yaml:"kubeconfig" required:"false"`
+	SQLITEDB   string `json:"db_path" yaml:"db_path" default:".dev-kit/devkit.sqlite3"`
+
+	// HOME_FOLDER and CURRENT_CMD are set at runtime and never saved
+	HOME_FOLDER string `json:"-" yaml:"-"`
 
 	CHECKED_TOOLS bool `json:"checked_tools" yaml:"checked_tools" required:"true"`
 
-	Projects    []ProjectConfig `json:"projects"`
-	CURRENT_CMD string          `json:"_"`
-}
+	// SECRETS_KEY_FILE is the key encrypting the secrets store, which is
+	// derived from a passphrase when it is empty
+	SECRETS_KEY_FILE string `json:"secrets_key_file" yaml:"secrets_key_file" required:"false"`
 
-// LoadConfig loads the configuration from a file or environment variables
-func LoadConfig(configPath string) (*GlobalConfig, error) {
-	if configPath != "" {
-		if err := loadConfigFromFile(configPath); err != nil {
-			return nil, err
-		}
-	}
+	// WORKSPACE is the directory the repositories of the projects are cloned
+	// into, each project in a directory of its own
+	WORKSPACE string `json:"workspace" yaml:"workspace" default:"~/devkit" required:"false"`
 
-	return loadDefaultConfig()
-}
+	// TOOLS overrides the tools checked by "devkit init check"
+	TOOLS []ToolRequirement `json:"tools,omitempty" yaml:"tools,omitempty"`
 
-func loadConfigFromFile(configPath string) error {
-	if _, err := os.Stat(configPath); err == nil {
-		data, err := os.ReadFile(configPath)
-		if err != nil {
-			logrus.Errorf("error reading config file: %v", err)
-			return err
-		}
+	// LAST_PROJECT_ID is the last ID handed out by GetProjectNewId. The IDs
+	// are never reused, as the logs, state and secrets of a project are kept
+	// by ID.
+	LAST_PROJECT_ID int `json:"last_project_id,omitempty" yaml:"last_project_id,omitempty"`
 
-		if err = yaml.Unmarshal(data, &globalConfig); err != nil {
-			logrus.Errorf("error unmarshalling config file: %v", err)
-			return err
-		}
+	Projects    []ProjectConfig `json:"projects"`
+	CURRENT_CMD string          `json:"-" yaml:"-"`
 
-		return validateAndSetDefaults(globalConfig)
-	} else if os.IsNotExist(err) {
-		logrus.Warn("Configuration file does not exist.")
-		return nil
-	} else {
-		logrus.Errorf("error checking config file: %v", err)
-		return err
-	}
+	// set tells which settings are saved in the user configuration, false
+	// for the ones unset since it was read, and origins where the value in
+	// effect of each setting comes from
+	set     map[string]bool
+	origins map[string]Origin
+}
+
+// LoadConfig loads the configuration. Its settings are layered, from the
+// lowest precedence to the highest: the built-in defaults, the system
+// configuration, the user configuration, the .devkit.yaml of the current
+// repository, the DEVKIT_* environment variables and configPath, the file given
+// with --config. Projects and tools only come from the user configuration,
+// which is the one devkit saves.
+func LoadConfig(configPath string) (*GlobalConfig, error) {
+	flagConfigPath = configPath
+	return loadDefaultConfig()
 }
 
 func loadDefaultConfig() (*GlobalConfig, error) {
@@ -134,13 +266,27 @@ func loadDefaultConfig() (*GlobalConfig, error) {
 		return nil, err
 	}
 
-	if err = yaml.Unmarshal(data, &globalConfig); err != nil {
+	// older configurations are upgraded in memory, the file itself on the
+	// next save or by "devkit config migrate"
+	cfg := &GlobalConfig{}
+	from, err := DecodeConfig(data, cfg)
+	if err != nil {
 		logrus.Errorf("error unmarshalling default config file: %v", err)
 		return nil, err
 	}
-	globalConfig.HOME_FOLDER = home
+	if from < CurrentVersion {
+		logrus.Debugf("upgraded %s from version %d to %d in memory", defaultConfigPath, from, CurrentVersion)
+	}
+	if err := validateAndSetDefaults(cfg); err != nil {
+		return nil, err
+	}
+	if err := cfg.applyLayers(); err != nil {
+		return nil, err
+	}
+	cfg.HOME_FOLDER = home
 
-	return globalConfig, validateAndSetDefaults(globalConfig)
+	globalConfig = cfg
+	return globalConfig, nil
 }
 
 // CreateDefaultConfig creates the default configuration file
@@ -149,7 +295,7 @@ func CreateDefaultConfig() (*GlobalConfig, error) {
 		return nil, err
 	}
 
-	data, err := yaml.Marshal(defaultConfig)
+	data, err := encodeConfig(&defaultConfig)
 	if err != nil {
 		logrus.Errorf("error marshalling default config: %v", err)
 		return nil, err
@@ -169,7 +315,7 @@ func CreateDefaultConfig() (*GlobalConfig, error) {
 
 	defaultConfigPath = filepath.Join(devKitDir, defaultConfigFileName)
 	if _, err := os.Stat(defaultConfigPath); os.IsNotExist(err) {
-		if err := os.WriteFile(defaultConfigPath, data, 0644); err != nil {
+		if err := WriteFileAtomic(defaultConfigPath, data, 0644); err != nil {
 			logrus.Errorf("error writing default config file: %v", err)
 			return nil, err
 		}
@@ -208,6 +354,12 @@ func validateAndSetDefaults(cfg *GlobalConfig) error {
 				if field.Bool() && defaultVal == "true" {
 					field.SetBool(true)
 				}
+			case reflect.Int:
+				if field.Int() == 0 {
+					if err := setField(field, defaultVal); err != nil {
+						errors = append(errors, fmt.Sprintf("invalid default of %s: %v", fieldType.Name, err))
+					}
+				}
 			}
 		}
 	}
@@ -223,25 +375,202 @@ func ValidateConfig(check *GlobalConfig) error {
 		// logrus.Errorf("tools are not checked, start with \"devkit check\" command, so that this tool can confirm all needed tools")
 		return errors.New("tools are not checked, start with \"devkit check\" command, so that this tool can confirm all needed tools")
 	}
+	return check.Validate()
+}
+
+// Validate checks the structure of the configuration shared by every command.
+// The projects are checked on their own by ProjectConfig.Validate, so that a
+// broken project does not prevent working with the other ones.
+func (cfg *GlobalConfig) Validate() error {
+	ids := map[string]bool{}
+	for _, project := range cfg.Projects {
+		if ids[project.ID] {
+			return fmt.Errorf("several projects have the ID '%s', IDs have to be unique", project.ID)
+		}
+		ids[project.ID] = true
+	}
+	return nil
+}
+
+// ValidateProjects checks every project, returning the problems found
+func (cfg *GlobalConfig) ValidateProjects() []error {
+	var problems []error
+	for i := range cfg.Projects {
+		if err := cfg.Projects[i].Validate(); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	return problems
+}
+
+// Validate checks the values and the dependencies of the environments of the
+// project
+func (p *ProjectConfig) Validate() error {
+	for _, env := range p.Environments {
+		if err := validateEnvironment(env); err != nil {
+			return fmt.Errorf("project '%s', environment '%s': %v", p.Name, env.Name, err)
+		}
+	}
+	if _, err := p.SortEnvironments(); err != nil {
+		return fmt.Errorf("project '%s': %v", p.Name, err)
+	}
+	return nil
+}
+
+// validateEnvironment checks the values of an environment which only have a
+// fixed set of accepted values
+func validateEnvironment(env EnvironmentConfig) error {
+	switch env.Restart {
+	case "", RestartNever, RestartOnFailure, RestartAlways:
+	default:
+		return fmt.Errorf("unknown restart policy '%s', use one of %s, %s or %s", env.Restart, RestartNever, RestartOnFailure, RestartAlways)
+	}
+	if env.MaxRestarts < 0 {
+		return fmt.Errorf("max_restarts cannot be negative")
+	}
+	if _, _, err := env.Ready.Durations(); err != nil {
+		return err
+	}
 	return nil
 }
 
+// DevKitPath returns the path of elem inside the ~/.dev-kit directory, creating
+// the directory holding it
+func DevKitPath(elem ...string) (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", fmt.Errorf("error getting home directory: %v", err)
+	}
+
+	path := filepath.Join(append([]string{home, devKitDirName}, elem...)...)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return "", fmt.Errorf("error creating %s: %v", filepath.Dir(path), err)
+	}
+	return path, nil
+}
+
 func GetConfig() *GlobalConfig {
 	return globalConfig
 }
 
-func (cfg *GlobalConfig) UpdateConfig() {
-	data, _ := yaml.Marshal(cfg)
-	os.WriteFile(defaultConfigPath, data, 0644)
+// UpdateConfig saves the configuration, holding the lock of the file
+func (cfg *GlobalConfig) UpdateConfig() error {
+	return WithLock(defaultConfigPath, func() error {
+		return writeConfig(cfg)
+	})
 }
 
-func UpdateConfig(config *GlobalConfig) {
-	data, _ := yaml.Marshal(config)
-	os.WriteFile(defaultConfigPath, data, 0644)
+func UpdateConfig(config *GlobalConfig) error {
+	return config.UpdateConfig()
+}
+
+// Update runs a read-modify-write cycle of the configuration file while holding
+// its lock. The file is loaded again, so that the changes saved by other devkit
+// processes in the meantime are kept, modified by fn and saved. The loaded
+// configuration becomes the one returned by GetConfig.
+func Update(fn func(cfg *GlobalConfig) error) error {
+	return WithLock(defaultConfigPath, func() error {
+		data, err := os.ReadFile(defaultConfigPath)
+		if err != nil {
+			return fmt.Errorf("error reading config file: %v", err)
+		}
+		cfg := &GlobalConfig{}
+		if _, err := DecodeConfig(data, cfg); err != nil {
+			return fmt.Errorf("error unmarshalling config file: %v", err)
+		}
+		if err := validateAndSetDefaults(cfg); err != nil {
+			return err
+		}
+		if globalConfig != nil {
+			cfg.HOME_FOLDER, cfg.CURRENT_CMD = globalConfig.HOME_FOLDER, globalConfig.CURRENT_CMD
+		}
+
+		// fn changes the user configuration, the other layers are applied
+		// once it is saved
+		before := cfg.settingValues()
+		if err := fn(cfg); err != nil {
+			return err
+		}
+		cfg.markChanged(before)
+		if err := writeConfig(cfg); err != nil {
+			return err
+		}
+		if err := cfg.applyLayers(); err != nil {
+			return err
+		}
+		globalConfig = cfg
+		return nil
+	})
+}
+
+// ParseConfig decodes and validates a configuration file
+func ParseConfig(data []byte) (*GlobalConfig, error) {
+	cfg := &GlobalConfig{}
+	if _, err := DecodeConfig(data, cfg); err != nil {
+		return nil, err
+	}
+	if err := validateAndSetDefaults(cfg); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+	if problems := cfg.ValidateProjects(); len(problems) > 0 {
+		return cfg, problems[0]
+	}
+	// the tasks are otherwise only checked when they are run
+	for i := range cfg.Projects {
+		if err := cfg.Projects[i].validateTasks(); err != nil {
+			return cfg, fmt.Errorf("project '%s', %v", cfg.Projects[i].Name, err)
+		}
+	}
+	return cfg, nil
+}
+
+// ReplaceConfig saves data, edited by hand, as the configuration file once it
+// is validated. It fails when the file no longer holds previous, so that the
+// changes saved by other devkit processes in the meantime are not lost.
+func ReplaceConfig(previous, data []byte) error {
+	if _, err := ParseConfig(data); err != nil {
+		return err
+	}
+	return WithLock(defaultConfigPath, func() error {
+		current, err := os.ReadFile(defaultConfigPath)
+		if err != nil {
+			return fmt.Errorf("error reading config file: %v", err)
+		}
+		if !bytes.Equal(current, previous) {
+			return fmt.Errorf("%s was changed by another devkit in the meantime", defaultConfigPath)
+		}
+		return WriteFileAtomic(defaultConfigPath, data, 0644)
+	})
+}
+
+func writeConfig(cfg *GlobalConfig) error {
+	data, err := encodeConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("error marshalling config: %v", err)
+	}
+	return WriteFileAtomic(defaultConfigPath, data, 0644)
 }
 
 // ValidateEnv checks if an environment with the same name already exists in the project
 func (cfg *GlobalConfig) ValidateEnv(projectID, envName, path string) error {
+	if err := cfg.ValidateEnvName(projectID, envName); err != nil {
+		return err
+	}
+
+	// Validate the path (you can add more specific path validation as needed)
+	if path == "" {
+		return fmt.Errorf("path cannot be empty")
+	}
+
+	return nil
+}
+
+// ValidateEnvName checks that the project exists and has no environment with
+// the same name
+func (cfg *GlobalConfig) ValidateEnvName(projectID, envName string) error {
 	// Check if the project exists by ID
 	var project *ProjectConfig
 	for _, p := range cfg.Projects {
@@ -261,17 +590,27 @@ func (cfg *GlobalConfig) ValidateEnv(projectID, envName, path string) error {
 			return fmt.Errorf("environment with name '%s' already exists in project with ID '%s'", envName, projectID)
 		}
 	}
-
-	// Validate the path (you can add more specific path validation as needed)
-	if path == "" {
-		return fmt.Errorf("path cannot be empty")
-	}
-
 	return nil
 }
 
-// ValidateProject checks if a project with the same name already exists
+// ValidateProject checks that a new project can be given the name, and that no
+// project has it already
 func (cfg *GlobalConfig) ValidateProject(projectName string) error {
+	if strings.TrimSpace(projectName) == "" {
+		return fmt.Errorf("project name cannot be empty")
+	}
+	if strings.TrimSpace(projectName) != projectName {
+		// the references are trimmed
+		return fmt.Errorf("project name '%s' cannot start or end with spaces", projectName)
+	}
+	// the name is used in "<project>/<env>" references and as the directory
+	// of the project in the workspace
+	if strings.ContainsAny(projectName, `/\`) || strings.Contains(projectName, "..") {
+		return fmt.Errorf("project name '%s' cannot contain '/', '\\' or '..'", projectName)
+	}
+	if _, err := strconv.Atoi(projectName); err == nil {
+		return fmt.Errorf("project name '%s' would be taken for an ID, use a name which is not a number", projectName)
+	}
 	for _, project := range cfg.Projects {
 		if project.Name == projectName {
 			return fmt.Errorf("project with name '%s' already exists", projectName)
@@ -280,7 +619,16 @@ func (cfg *GlobalConfig) ValidateProject(projectName string) error {
 	return nil
 }
 
+// GetProjectNewId hands out the ID of a new project, following the last one
+// handed out and the highest numeric one, and records it in the configuration
+// so that the IDs of removed projects are never handed out again
 func (cfg *GlobalConfig) GetProjectNewId() int {
-	// Generate a new project ID based on the current number of projects
-	return len(cfg.Projects) + 1
+	id := cfg.LAST_PROJECT_ID + 1
+	for _, project := range cfg.Projects {
+		if n, err := strconv.Atoi(project.ID); err == nil && n >= id {
+			id = n + 1
+		}
+	}
+	cfg.LAST_PROJECT_ID = id
+	return id
 }
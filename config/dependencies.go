@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SortEnvironments returns the environments of the project ordered so that
+// every environment comes after the ones it depends on, keeping the declared
+// order otherwise. It fails on unknown dependencies and dependency cycles.
+func (p *ProjectConfig) SortEnvironments() ([]EnvironmentConfig, error) {
+	byName := map[string][]int{}
+	for i, env := range p.Environments {
+		byName[env.Name] = append(byName[env.Name], i)
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]int, len(p.Environments))
+	sorted := make([]EnvironmentConfig, 0, len(p.Environments))
+	var path []string
+
+	var visit func(i int) error
+	visit = func(i int) error {
+		env := p.Environments[i]
+		switch state[i] {
+		case done:
+			return nil
+		case visiting:
+			start := 0
+			for j, name := range path {
+				if name == env.Name {
+					start = j
+				}
+			}
+			cycle := append(append([]string{}, path[start:]...), env.Name)
+			return fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " -> "))
+		}
+
+		state[i] = visiting
+		path = append(path, env.Name)
+		for _, dep := range env.DependsOn {
+			if dep == env.Name {
+				return fmt.Errorf("environment '%s' cannot depend on itself", env.Name)
+			}
+			deps, ok := byName[dep]
+			if !ok {
+				return fmt.Errorf("environment '%s' depends on unknown environment '%s'", env.Name, dep)
+			}
+			for _, j := range deps {
+				if err := visit(j); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = done
+		sorted = append(sorted, env)
+		return nil
+	}
+
+	for i := range p.Environments {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
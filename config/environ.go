@@ -0,0 +1,193 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ResolveEnv returns the variables set for env, layering the project
+// variables, the .env files of env and its own variables, each overriding the
+// previous ones. Values may reference other variables as ${VAR}, which are
+// looked up in the same or a lower layer, and finally in base (usually the
+// environment of devkit itself). A variable referencing itself gets the value
+// of the lower layers, e.g. PATH: "${PATH}:./bin".
+func (p *ProjectConfig) ResolveEnv(env *EnvironmentConfig, base map[string]string) (map[string]string, error) {
+	layers := []map[string]string{p.Env}
+	for _, file := range env.EnvFiles {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(env.Path, file)
+		}
+		vars, err := readEnvFile(file)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, vars)
+	}
+	layers = append(layers, env.Env)
+
+	lookup := make(map[string]string, len(base))
+	for k, v := range base {
+		lookup[k] = v
+	}
+	resolved := map[string]string{}
+	for _, layer := range layers {
+		expanded, err := expandLayer(lookup, layer)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range expanded {
+			lookup[k] = v
+			resolved[k] = v
+		}
+	}
+	return resolved, nil
+}
+
+// expandLayer interpolates the values of layer against the layer itself and
+// the variables of the lower layers
+func expandLayer(lower, layer map[string]string) (map[string]string, error) {
+	expanded := make(map[string]string, len(layer))
+
+	var resolve func(key string, stack []string) (string, error)
+	resolve = func(key string, stack []string) (string, error) {
+		if v, ok := expanded[key]; ok {
+			return v, nil
+		}
+		for _, k := range stack {
+			if k == key {
+				return "", fmt.Errorf("variable cycle: %s -> %s", strings.Join(stack, " -> "), key)
+			}
+		}
+		stack = append(stack, key)
+
+		var err error
+		v := interpolate(layer[key], func(ref string) string {
+			if _, ok := layer[ref]; !ok || ref == key || err != nil {
+				return lower[ref]
+			}
+			var value string
+			value, err = resolve(ref, stack)
+			return value
+		})
+		if err != nil {
+			return "", err
+		}
+		expanded[key] = v
+		return v, nil
+	}
+
+	keys := make([]string, 0, len(layer))
+	for k := range layer {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if _, err := resolve(k, nil); err != nil {
+			return nil, err
+		}
+	}
+	return expanded, nil
+}
+
+// interpolate replaces every ${VAR} of value, "$${" escapes a literal "${"
+func interpolate(value string, lookup func(string) string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(value, "${")
+		if i < 0 {
+			b.WriteString(value)
+			return b.String()
+		}
+		if i > 0 && value[i-1] == '$' {
+			b.WriteString(value[:i])
+			b.WriteString("{")
+			value = value[i+2:]
+			continue
+		}
+		end := strings.Index(value[i:], "}")
+		if end < 0 {
+			b.WriteString(value)
+			return b.String()
+		}
+		b.WriteString(value[:i])
+		b.WriteString(lookup(value[i+2 : i+end]))
+		value = value[i+end+1:]
+	}
+}
+
+// readEnvFile parses a .env file of KEY=VALUE lines. Lines may start with
+// "export", "#" starts a comment, single quoted values are taken literally
+// (they are not interpolated either) and double quoted values support \n, \t,
+// \" and \\ escapes.
+func readEnvFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading env file: %v", err)
+	}
+	defer file.Close()
+
+	vars := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, n)
+		}
+
+		vars[key] = parseEnvValue(strings.TrimSpace(value))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading env file: %v", err)
+	}
+	return vars, nil
+}
+
+// parseEnvValue unquotes a value of a .env file and strips trailing comments
+func parseEnvValue(value string) string {
+	if value == "" {
+		return value
+	}
+
+	switch value[0] {
+	case '\'':
+		if end := strings.IndexByte(value[1:], '\''); end >= 0 {
+			return strings.ReplaceAll(value[1:end+1], "${", "$${")
+		}
+	case '"':
+		var b strings.Builder
+		for i := 1; i < len(value); i++ {
+			c := value[i]
+			if c == '"' {
+				return b.String()
+			}
+			if c == '\\' && i+1 < len(value) {
+				i++
+				switch value[i] {
+				case 'n':
+					c = '\n'
+				case 't':
+					c = '\t'
+				default:
+					c = value[i]
+				}
+			}
+			b.WriteByte(c)
+		}
+	}
+
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value
+}
@@ -0,0 +1,281 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// Layers of the configuration, from the lowest precedence to the highest
+const (
+	LayerDefault = "default"
+	LayerSystem  = "system"
+	LayerUser    = "user"
+	LayerLocal   = "local"
+	LayerEnv     = "env"
+	LayerFlag    = "flag"
+)
+
+const (
+	// LocalConfigFileName is the configuration of a repository, looked up
+	// from the current directory up to the root
+	LocalConfigFileName = ".devkit.yaml"
+
+	// EnvPrefix prefixes the environment variables overriding the settings,
+	// e.g. DEVKIT_LOG_FORMAT for log_format
+	EnvPrefix = "DEVKIT_"
+)
+
+// flagConfigPath is the file given with --config
+var flagConfigPath string
+
+// ignoredWarned records the files whose projects or tools were reported as
+// ignored, once per run
+var ignoredWarned = map[string]bool{}
+
+// Origin tells where the value of a setting comes from
+type Origin struct {
+	Layer string `json:"layer"`
+
+	// Source is the file or the environment variable holding the value, empty
+	// for the defaults
+	Source string `json:"source,omitempty"`
+}
+
+func (o Origin) String() string {
+	if o.Source == "" {
+		return o.Layer
+	}
+	return fmt.Sprintf("%s (%s)", o.Layer, o.Source)
+}
+
+// layer holds the settings set by one layer of the configuration
+type layer struct {
+	origin Origin
+	values map[string]string
+}
+
+// SystemConfigPath returns the path of the configuration shared by the users
+// of the machine
+func SystemConfigPath() string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("ProgramData"), "devkit", defaultConfigFileName)
+	}
+	return filepath.Join("/etc/devkit", defaultConfigFileName)
+}
+
+// LocalConfigPath returns the .devkit.yaml of the repository holding the
+// current directory, empty when there is none
+func LocalConfigPath() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, LocalConfigFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Origin returns where the value in effect of a setting comes from
+func (cfg *GlobalConfig) Origin(key string) Origin {
+	if origin, ok := cfg.origins[key]; ok {
+		return origin
+	}
+	if cfg.set[key] {
+		return Origin{Layer: LayerUser, Source: defaultConfigPath}
+	}
+	return Origin{Layer: LayerDefault}
+}
+
+// applyLayers sets the settings of cfg, holding the user configuration, to
+// the values of the layer with the highest precedence setting them
+func (cfg *GlobalConfig) applyLayers() error {
+	layers, err := cfg.layers()
+	if err != nil {
+		return err
+	}
+
+	cfg.origins = map[string]Origin{}
+	for _, setting := range Settings() {
+		origin := Origin{Layer: LayerDefault}
+		for _, l := range layers {
+			value, ok := l.values[setting.Key]
+			if !ok {
+				continue
+			}
+			_, field, _ := cfg.settingValue(setting.Key)
+			if err := setField(field, value); err != nil {
+				return fmt.Errorf("invalid value '%s' for %s in %s: %v", value, setting.Key, l.origin, err)
+			}
+			origin = l.origin
+		}
+		cfg.origins[setting.Key] = origin
+	}
+	return validateAndSetDefaults(cfg)
+}
+
+// layers returns the layers setting a value, by increasing precedence
+func (cfg *GlobalConfig) layers() ([]layer, error) {
+	var layers []layer
+	add := func(origin Origin, values map[string]string, err error) error {
+		if err != nil {
+			return err
+		}
+		if len(values) > 0 {
+			layers = append(layers, layer{origin: origin, values: values})
+		}
+		return nil
+	}
+
+	system := SystemConfigPath()
+	values, err := fileSettings(system)
+	if err := add(Origin{Layer: LayerSystem, Source: system}, values, err); err != nil {
+		return nil, err
+	}
+
+	// the user configuration goes on top of the system one
+	user := map[string]string{}
+	for key, set := range cfg.set {
+		if set {
+			user[key], _ = cfg.Get(key)
+		}
+	}
+	_ = add(Origin{Layer: LayerUser, Source: defaultConfigPath}, user, nil)
+
+	if local := LocalConfigPath(); local != "" {
+		values, err := fileSettings(local)
+		if err := add(Origin{Layer: LayerLocal, Source: local}, values, err); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, setting := range Settings() {
+		name := EnvPrefix + strings.ToUpper(setting.Key)
+		if value, ok := os.LookupEnv(name); ok {
+			_ = add(Origin{Layer: LayerEnv, Source: name}, map[string]string{setting.Key: value}, nil)
+		}
+	}
+
+	if flagConfigPath != "" {
+		values, err := fileSettings(flagConfigPath)
+		if err == nil && values == nil {
+			if _, statErr := os.Stat(flagConfigPath); statErr != nil {
+				err = fmt.Errorf("error reading config file: %v", statErr)
+			}
+		}
+		if err := add(Origin{Layer: LayerFlag, Source: flagConfigPath}, values, err); err != nil {
+			return nil, err
+		}
+	}
+	return layers, nil
+}
+
+// fileSettings returns the settings of a configuration file written by hand,
+// nil when it does not exist. Only the settings are read, the projects and
+// tools are the ones of the user configuration.
+func fileSettings(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", path, err)
+	}
+
+	doc := map[string]any{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("error unmarshalling %s: %v", path, err)
+	}
+	if _, ok := doc["version"]; !ok {
+		// written for the current schema, the migrations only upgrade the
+		// files saved by devkit
+		doc["version"] = CurrentVersion
+	}
+	if _, _, err := migrate(doc); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for _, key := range []string{"projects", "tools"} {
+		if _, ok := doc[key]; ok && !ignoredWarned[path+key] {
+			ignoredWarned[path+key] = true
+			logrus.Warnf("%s: %s are only read from %s, ignoring them", path, key, defaultConfigPath)
+		}
+	}
+
+	values := map[string]string{}
+	for _, setting := range Settings() {
+		if value, ok := doc[setting.Key]; ok && value != nil {
+			values[setting.Key] = fmt.Sprint(value)
+		}
+	}
+	return values, nil
+}
+
+// settingValues returns the values of the settings, to find the ones changed
+// later on with markChanged
+func (cfg *GlobalConfig) settingValues() map[string]string {
+	values := map[string]string{}
+	for _, setting := range Settings() {
+		values[setting.Key], _ = cfg.Get(setting.Key)
+	}
+	return values
+}
+
+// markChanged saves the settings changed since before in the user
+// configuration, unless they were explicitly set or unset
+func (cfg *GlobalConfig) markChanged(before map[string]string) {
+	for key, value := range cfg.settingValues() {
+		if _, decided := cfg.set[key]; !decided && value != before[key] {
+			cfg.markSet(key, true)
+		}
+	}
+}
+
+func (cfg *GlobalConfig) markSet(key string, set bool) {
+	if cfg.set == nil {
+		cfg.set = map[string]bool{}
+	}
+	cfg.set[key] = set
+}
+
+// encodeConfig marshals the user configuration, leaving out the settings it
+// does not set so that the other layers apply
+func encodeConfig(cfg *GlobalConfig) ([]byte, error) {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	mapping := doc.Content[0]
+	settings := map[string]bool{}
+	for _, setting := range Settings() {
+		settings[setting.Key] = true
+	}
+	var content []*yaml.Node
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key := mapping.Content[i].Value
+		if settings[key] && !cfg.set[key] {
+			continue
+		}
+		content = append(content, mapping.Content[i], mapping.Content[i+1])
+	}
+	mapping.Content = content
+	return yaml.Marshal(&doc)
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrProjectNotFound is returned by FindProject when no project has the ID or
+// name
+var ErrProjectNotFound = errors.New("does not exist")
+
+// FindProject returns the project whose ID or name matches ref. IDs take
+// precedence over names, and a name shared by several projects is reported as
+// ambiguous.
+func (cfg *GlobalConfig) FindProject(ref string) (*ProjectConfig, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, fmt.Errorf("project ID or name cannot be empty")
+	}
+
+	for i := range cfg.Projects {
+		if cfg.Projects[i].ID == ref {
+			return cfg.uniqueProject(i)
+		}
+	}
+
+	var matches []int
+	for i := range cfg.Projects {
+		if cfg.Projects[i].Name == ref {
+			matches = append(matches, i)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("project '%s' %w", ref, ErrProjectNotFound)
+	case 1:
+		return cfg.uniqueProject(matches[0])
+	}
+
+	ids := make([]string, len(matches))
+	for i, idx := range matches {
+		ids[i] = cfg.Projects[idx].ID
+	}
+	return nil, fmt.Errorf("project name '%s' is ambiguous, use one of the IDs: %s", ref, strings.Join(ids, ", "))
+}
+
+// uniqueProject returns the project at index i, unless other projects have its
+// ID: the changes made to it would be made to the first of them
+func (cfg *GlobalConfig) uniqueProject(i int) (*ProjectConfig, error) {
+	id := cfg.Projects[i].ID
+	var names []string
+	for _, p := range cfg.Projects {
+		if p.ID == id {
+			names = append(names, p.Name)
+		}
+	}
+	if len(names) > 1 {
+		return nil, fmt.Errorf("projects %s have the same ID '%s', give them unique IDs with \"devkit config edit\"", strings.Join(names, ", "), id)
+	}
+	return &cfg.Projects[i], nil
+}
+
+// FindTargets resolves a "<project>[/<env>]" reference to the project and the
+// environments it references, the single environment or every environment of
+// the project.
+func (cfg *GlobalConfig) FindTargets(ref string) (*ProjectConfig, []EnvironmentConfig, error) {
+	if strings.Contains(ref, "/") {
+		project, env, err := cfg.FindEnvironment(ref)
+		if err != nil {
+			return nil, nil, err
+		}
+		return project, []EnvironmentConfig{*env}, nil
+	}
+	project, err := cfg.FindProject(ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(project.Environments) == 0 {
+		return nil, nil, fmt.Errorf("project '%s' has no environments", project.Name)
+	}
+	return project, project.Environments, nil
+}
+
+// FindEnvironment resolves a "<project>/<env>" reference, where the project is
+// an ID or name and the environment is a name or its 1-based position within
+// the project.
+func (cfg *GlobalConfig) FindEnvironment(ref string) (*ProjectConfig, *EnvironmentConfig, error) {
+	projectRef, envRef, ok := strings.Cut(ref, "/")
+	if !ok || strings.TrimSpace(envRef) == "" {
+		return nil, nil, fmt.Errorf("environment reference '%s' must be in the form <project>/<env>", ref)
+	}
+
+	project, err := cfg.FindProject(projectRef)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	env, err := project.FindEnvironment(envRef)
+	if err != nil {
+		return nil, nil, err
+	}
+	return project, env, nil
+}
+
+// FindEnvironment returns the environment of the project matching ref by name,
+// or by its 1-based position when no name matches.
+func (p *ProjectConfig) FindEnvironment(ref string) (*EnvironmentConfig, error) {
+	ref = strings.TrimSpace(ref)
+
+	var matches []int
+	for i := range p.Environments {
+		if p.Environments[i].Name == ref {
+			matches = append(matches, i)
+		}
+	}
+
+	switch len(matches) {
+	case 1:
+		return &p.Environments[matches[0]], nil
+	case 0:
+		if idx, err := strconv.Atoi(ref); err == nil && idx >= 1 && idx <= len(p.Environments) {
+			return &p.Environments[idx-1], nil
+		}
+		return nil, fmt.Errorf("environment '%s' does not exist in project '%s'", ref, p.Name)
+	}
+
+	positions := make([]string, len(matches))
+	for i, idx := range matches {
+		positions[i] = strconv.Itoa(idx + 1)
+	}
+	return nil, fmt.Errorf("environment name '%s' is ambiguous in project '%s', use one of the positions: %s", ref, p.Name, strings.Join(positions, ", "))
+}
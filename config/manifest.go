@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFileName is the manifest written in the directory of a project
+// cloned by "devkit project clone"
+const ManifestFileName = "devkit.manifest.yaml"
+
+// Manifest lists the repositories making up a project, typically the services
+// of a microservice project, so that it can be cloned again in one go
+type Manifest struct {
+	Project      string       `json:"project" yaml:"project"`
+	Description  string       `json:"description,omitempty" yaml:"description,omitempty"`
+	Microservice bool         `json:"microservice,omitempty" yaml:"microservice,omitempty"`
+	Repositories []Repository `json:"repositories" yaml:"repositories"`
+}
+
+// Repository is a git repository of a manifest, registered as an environment
+// of the project once cloned
+type Repository struct {
+	// URL is anything accepted by "git clone", including local paths
+	URL string `json:"url" yaml:"url"`
+
+	// Name is the name of the environment, the last element of URL without
+	// its .git suffix by default
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+
+	// Branch is checked out instead of the default branch of the repository
+	Branch string `json:"branch,omitempty" yaml:"branch,omitempty"`
+
+	// Path is the directory of the clone, relative to the directory of the
+	// project in the workspace, Name by default
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+}
+
+// ReadManifest reads and checks a manifest file
+func ReadManifest(file string) (*Manifest, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest: %v", err)
+	}
+	manifest := &Manifest{}
+	if err := yaml.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("error unmarshalling manifest %s: %v", file, err)
+	}
+	if len(manifest.Repositories) == 0 {
+		return nil, fmt.Errorf("manifest %s lists no repositories", file)
+	}
+
+	names := map[string]bool{}
+	for i := range manifest.Repositories {
+		repo := &manifest.Repositories[i]
+		if repo.URL == "" {
+			return nil, fmt.Errorf("manifest %s: repository %d has no url", file, i+1)
+		}
+		repo.SetDefaults()
+		if names[repo.Name] {
+			return nil, fmt.Errorf("manifest %s: several repositories are named '%s', set their name", file, repo.Name)
+		}
+		names[repo.Name] = true
+	}
+	return manifest, nil
+}
+
+// SetDefaults fills the name and path of the repository from its URL
+func (r *Repository) SetDefaults() {
+	if r.Name == "" {
+		r.Name = RepositoryName(r.URL)
+	}
+	if r.Path == "" {
+		r.Path = r.Name
+	}
+}
+
+// RepositoryName returns the last element of a repository URL or path, without
+// its .git suffix, e.g. api for git@github.com:shop/api.git
+func RepositoryName(url string) string {
+	url = strings.TrimRight(filepath.ToSlash(url), "/")
+	if i := strings.LastIndexAny(url, "/:"); i >= 0 {
+		url = url[i+1:]
+	}
+	return strings.TrimSuffix(url, ".git")
+}
+
+// Manifest returns the manifest of the environments of the project cloned from
+// a repository, with their paths relative to dir when they are below it
+func (p *ProjectConfig) Manifest(dir string) *Manifest {
+	manifest := &Manifest{
+		Project:      p.Name,
+		Description:  p.Description,
+		Microservice: p.IsMicroservice,
+		Repositories: []Repository{},
+	}
+	for _, env := range p.Environments {
+		if env.Repository == "" {
+			continue
+		}
+		repo := Repository{URL: env.Repository, Name: env.Name, Branch: env.Branch}
+		if rel, err := filepath.Rel(dir, env.Path); err == nil && filepath.IsLocal(rel) {
+			repo.Path = filepath.ToSlash(rel)
+		}
+		if repo.Path == repo.Name {
+			repo.Path = ""
+		}
+		if RepositoryName(env.Repository) == repo.Name {
+			repo.Name = ""
+		}
+		manifest.Repositories = append(manifest.Repositories, repo)
+	}
+	return manifest
+}
+
+// WorkspacePath returns the directory of a project in the workspace
+func (cfg *GlobalConfig) WorkspacePath(project string) (string, error) {
+	workspace, err := homedir.Expand(cfg.WORKSPACE)
+	if err != nil {
+		return "", fmt.Errorf("invalid workspace '%s': %v", cfg.WORKSPACE, err)
+	}
+	if workspace, err = filepath.Abs(workspace); err != nil {
+		return "", fmt.Errorf("invalid workspace '%s': %v", cfg.WORKSPACE, err)
+	}
+	return filepath.Join(workspace, project), nil
+}
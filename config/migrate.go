@@ -0,0 +1,243 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the version of the configuration schema written by this
+// devkit. Configurations without a version are at version 0.
+const CurrentVersion = 2
+
+// migration upgrades a decoded configuration document from the previous
+// version to Version
+type migration struct {
+	Version     int
+	Description string
+	Apply       func(doc map[string]any) error
+}
+
+// migrations is the registry of the schema changes, ordered by version. A
+// change of the schema appends a migration and bumps CurrentVersion.
+var migrations = []migration{
+	{Version: 1, Description: "use the same snake_case keys in YAML and JSON", Apply: migrateSnakeCaseKeys},
+	{Version: 2, Description: "drop the settings saved with their default value, which would hide the system and repository configurations", Apply: dropDefaultSettings},
+}
+
+// Migration describes the upgrade of a configuration file by MigrateFile
+type Migration struct {
+	Path string
+	From int
+	To   int
+
+	// Steps describes the migrations applied, in order
+	Steps []string
+
+	// Before is the content of the file and After the content it is upgraded
+	// to
+	Before []byte
+	After  []byte
+}
+
+// DecodeConfig decodes a YAML or JSON configuration into cfg, upgrading it to
+// the current schema first. It returns the version the configuration was at.
+func DecodeConfig(data []byte, cfg *GlobalConfig) (int, error) {
+	doc := map[string]any{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return 0, err
+	}
+	from, _, err := migrate(doc)
+	if err != nil {
+		return from, err
+	}
+	if from < CurrentVersion {
+		if data, err = yaml.Marshal(doc); err != nil {
+			return from, err
+		}
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return from, err
+	}
+
+	cfg.set = map[string]bool{}
+	for _, setting := range Settings() {
+		if _, ok := doc[setting.Key]; ok {
+			cfg.set[setting.Key] = true
+		}
+	}
+	return from, nil
+}
+
+// migrate applies the migrations newer than the version of doc, and returns
+// that version along with the descriptions of the migrations applied
+func migrate(doc map[string]any) (int, []string, error) {
+	from, err := docVersion(doc)
+	if err != nil {
+		return 0, nil, err
+	}
+	if from > CurrentVersion {
+		return from, nil, fmt.Errorf("the configuration is at version %d, which is newer than the version %d supported by this devkit, upgrade devkit", from, CurrentVersion)
+	}
+
+	var steps []string
+	for _, m := range migrations {
+		if m.Version <= from {
+			continue
+		}
+		if err := m.Apply(doc); err != nil {
+			return from, steps, fmt.Errorf("error migrating the configuration to version %d: %v", m.Version, err)
+		}
+		doc["version"] = m.Version
+		steps = append(steps, fmt.Sprintf("%d: %s", m.Version, m.Description))
+	}
+	return from, steps, nil
+}
+
+func docVersion(doc map[string]any) (int, error) {
+	switch version := doc["version"].(type) {
+	case nil:
+		return 0, nil
+	case int:
+		return version, nil
+	case float64:
+		// JSON numbers
+		if version == float64(int(version)) {
+			return int(version), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid configuration version '%v'", doc["version"])
+}
+
+// MigrateFile upgrades the configuration file at path to the current schema,
+// keeping its format, JSON for .json files and YAML otherwise. The upgraded
+// file is only written when dryRun is not set.
+func MigrateFile(path string, dryRun bool) (*Migration, error) {
+	result := &Migration{Path: path, To: CurrentVersion}
+	run := func() error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading config file: %v", err)
+		}
+		result.Before = data
+
+		doc := map[string]any{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("error unmarshalling config file: %v", err)
+		}
+		if result.From, result.Steps, err = migrate(doc); err != nil {
+			return err
+		}
+		if result.From == CurrentVersion {
+			result.After = data
+			return nil
+		}
+
+		// the file is written the way devkit saves it, with the keys of the
+		// current schema only
+		migrated, err := yaml.Marshal(doc)
+		if err != nil {
+			return fmt.Errorf("error marshalling config: %v", err)
+		}
+		cfg := &GlobalConfig{}
+		if _, err := DecodeConfig(migrated, cfg); err != nil {
+			return fmt.Errorf("error unmarshalling config file: %v", err)
+		}
+		if err := validateAndSetDefaults(cfg); err != nil {
+			return err
+		}
+		if strings.EqualFold(filepath.Ext(path), ".json") {
+			result.After, err = json.MarshalIndent(cfg, "", "  ")
+			result.After = append(result.After, '\n')
+		} else {
+			result.After, err = encodeConfig(cfg)
+		}
+		if err != nil {
+			return fmt.Errorf("error marshalling config: %v", err)
+		}
+
+		if dryRun {
+			return nil
+		}
+		return WriteFileAtomic(path, result.After, 0644)
+	}
+
+	if dryRun {
+		return result, run()
+	}
+	return result, WithLock(path, run)
+}
+
+// DefaultConfigPath returns the path of the configuration file of devkit
+func DefaultConfigPath() string {
+	return defaultConfigPath
+}
+
+// migrateSnakeCaseKeys renames the keys of version 0, which differed between
+// the YAML file of the CLI (lowercased field names) and the JSON file of the
+// server (mixed case), and drops the values which are only set at runtime
+func migrateSnakeCaseKeys(doc map[string]any) error {
+	renameKeys(doc, map[string]string{
+		"PPROF_ENABLED":      "pprof_enabled",
+		"PPROF_PORT":         "pprof_address",
+		"pprof_add_and_port": "pprof_address",
+		"LOG_FORMAT":         "log_format",
+		"KUBECONFIG":         "kubeconfig",
+		"sqlitedb":           "db_path",
+	})
+	for _, key := range []string{"home", "home_folder", "_", "current_cmd"} {
+		delete(doc, key)
+	}
+
+	projects, _ := doc["projects"].([]any)
+	for _, p := range projects {
+		project, ok := p.(map[string]any)
+		if !ok {
+			return fmt.Errorf("invalid project %v", p)
+		}
+		renameKeys(project, map[string]string{"ismicroservice": "is_microservice"})
+		delete(project, "isvalid")
+	}
+	return nil
+}
+
+// renameKeys renames the keys of doc, a value already set under the new key
+// takes precedence
+func renameKeys(doc map[string]any, renames map[string]string) {
+	for from, to := range renames {
+		value, ok := doc[from]
+		if !ok {
+			continue
+		}
+		delete(doc, from)
+		if _, exists := doc[to]; !exists {
+			doc[to] = value
+		}
+	}
+}
+
+// dropDefaultSettings removes the settings equal to their default value, which
+// devkit used to save along with the others. The settings and defaults are the
+// ones of version 2: the settings added or changed later were never saved this
+// way.
+func dropDefaultSettings(doc map[string]any) error {
+	defaults := map[string]string{
+		"debug":         "false",
+		"pprof_enabled": "false",
+		"pprof_address": "localhost:6060",
+		"log_format":    "text",
+		"kubeconfig":    "",
+		"db_path":       ".dev-kit/devkit.sqlite3",
+	}
+	for key, def := range defaults {
+		value, ok := doc[key]
+		if ok && (value == nil || fmt.Sprint(value) == def) {
+			delete(doc, key)
+		}
+	}
+	return nil
+}
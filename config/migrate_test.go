@@ -0,0 +1,155 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func decodeDoc(t *testing.T, data string) map[string]any {
+	t.Helper()
+	doc := map[string]any{}
+	if err := yaml.Unmarshal([]byte(data), &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+		from int
+		err  string
+	}{
+		{
+			name: "server keys of version 0",
+			doc:  "PPROF_PORT: localhost:7000\nLOG_FORMAT: json\nprojects:\n  - id: \"1\"\n    ismicroservice: true\n    isvalid: true\n",
+			want: "version: 2\npprof_address: localhost:7000\nlog_format: json\nprojects:\n  - id: \"1\"\n    is_microservice: true\n",
+		},
+		{
+			name: "cli keys of version 0",
+			doc:  "pprof_add_and_port: localhost:7000\ndebug: true\nhome_folder: /home/dev\ncurrent_cmd: init\n",
+			want: "version: 2\npprof_address: localhost:7000\ndebug: true\n",
+		},
+		{
+			name: "new keys take precedence",
+			doc:  "pprof_add_and_port: localhost:7000\npprof_address: localhost:8000\n",
+			want: "version: 2\npprof_address: localhost:8000\n",
+		},
+		{
+			name: "default values of version 1",
+			doc:  "version: 1\ndebug: false\npprof_address: localhost:6060\nlog_format: text\nkubeconfig: \"\"\ndb_path: .dev-kit/devkit.sqlite3\nchecked_tools: false\n",
+			want: "version: 2\nchecked_tools: false\n",
+			from: 1,
+		},
+		{
+			name: "current version",
+			doc:  "version: 2\nlog_format: text\n",
+			want: "version: 2\nlog_format: text\n",
+			from: 2,
+		},
+		{
+			name: "newer version",
+			doc:  "version: 3\n",
+			from: 3,
+			err:  "newer than the version 2",
+		},
+		{
+			name: "invalid version",
+			doc:  "version: two\n",
+			err:  "invalid configuration version",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := decodeDoc(t, tt.doc)
+			from, _, err := migrate(doc)
+			if from != tt.from {
+				t.Errorf("got version %d, want %d", from, tt.from)
+			}
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("got error %v, want one containing '%s'", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := decodeDoc(t, tt.want); !reflect.DeepEqual(doc, want) {
+				t.Errorf("got %v, want %v", doc, want)
+			}
+		})
+	}
+}
+
+func TestMigrateFile(t *testing.T) {
+	tests := []struct {
+		file string
+		data string
+	}{
+		{"config.json", `{"PPROF_PORT": "localhost:7000", "LOG_FORMAT": "json", "projects": [{"id": "1", "name": "shop", "ismicroservice": true}]}`},
+		{"config.yaml", "pprof_add_and_port: localhost:7000\nlog_format: json\nprojects:\n  - id: \"1\"\n    name: shop\n    ismicroservice: true\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := MigrateFile(path, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if data, _ := os.ReadFile(path); string(data) != tt.data {
+				t.Errorf("the dry run changed the file to %s", data)
+			}
+
+			result, err = MigrateFile(path, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.From != 0 || result.To != CurrentVersion || len(result.Steps) != 2 {
+				t.Errorf("got a migration from %d to %d in %d steps, want from 0 to %d in 2", result.From, result.To, len(result.Steps), CurrentVersion)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.HasSuffix(tt.file, ".json") && !json.Valid(data) {
+				t.Errorf("the JSON file is written as %s", data)
+			}
+			if bak, _ := os.ReadFile(path + ".bak"); string(bak) != tt.data {
+				t.Errorf("got backup %s, want the original file", bak)
+			}
+
+			cfg := &GlobalConfig{}
+			if from, err := DecodeConfig(data, cfg); err != nil || from != CurrentVersion {
+				t.Fatalf("got version %d and error %v decoding the migrated file", from, err)
+			}
+			if cfg.PPROF_ADD_AND_PORT != "localhost:7000" || cfg.LOG_FORMAT != "json" {
+				t.Errorf("got pprof_address '%s' and log_format '%s', want localhost:7000 and json", cfg.PPROF_ADD_AND_PORT, cfg.LOG_FORMAT)
+			}
+			if len(cfg.Projects) != 1 || cfg.Projects[0].Name != "shop" || !cfg.Projects[0].IsMicroservice {
+				t.Errorf("got projects %+v, want the microservice project shop", cfg.Projects)
+			}
+
+			// migrating the current version leaves the file untouched
+			result, err = MigrateFile(path, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.From != CurrentVersion || string(result.After) != string(data) {
+				t.Errorf("migrating again changed the file from version %d", result.From)
+			}
+		})
+	}
+}
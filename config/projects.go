@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// RemoveProject removes the project with the given ID, pointers to projects
+// are invalid afterwards
+func (cfg *GlobalConfig) RemoveProject(id string) error {
+	i := slices.IndexFunc(cfg.Projects, func(p ProjectConfig) bool { return p.ID == id })
+	if i < 0 {
+		return fmt.Errorf("project with ID '%s' does not exist", id)
+	}
+	cfg.Projects = slices.Delete(cfg.Projects, i, i+1)
+	return nil
+}
+
+// Dependents returns the names of the environments of the project depending on
+// the environment name
+func (p *ProjectConfig) Dependents(name string) []string {
+	var dependents []string
+	for _, env := range p.Environments {
+		if env.Name != name && slices.Contains(env.DependsOn, name) {
+			dependents = append(dependents, env.Name)
+		}
+	}
+	return dependents
+}
+
+// RemoveEnvironment removes an environment which no other environment depends
+// on
+func (p *ProjectConfig) RemoveEnvironment(name string) error {
+	i := slices.IndexFunc(p.Environments, func(e EnvironmentConfig) bool { return e.Name == name })
+	if i < 0 {
+		return fmt.Errorf("environment '%s' does not exist in project '%s'", name, p.Name)
+	}
+	if dependents := p.Dependents(name); len(dependents) > 0 {
+		return fmt.Errorf("environment '%s' is a dependency of %s, remove it from their depends_on first", name, strings.Join(dependents, ", "))
+	}
+	p.Environments = slices.Delete(p.Environments, i, i+1)
+	return nil
+}
+
+// RenameEnvironment renames an environment, updating the environments
+// depending on it
+func (p *ProjectConfig) RenameEnvironment(name, newName string) error {
+	if name == newName {
+		return nil
+	}
+	i := slices.IndexFunc(p.Environments, func(e EnvironmentConfig) bool { return e.Name == name })
+	if i < 0 {
+		return fmt.Errorf("environment '%s' does not exist in project '%s'", name, p.Name)
+	}
+	if strings.TrimSpace(newName) == "" {
+		return fmt.Errorf("environment name cannot be empty")
+	}
+	if slices.ContainsFunc(p.Environments, func(e EnvironmentConfig) bool { return e.Name == newName }) {
+		return fmt.Errorf("environment with name '%s' already exists in project '%s'", newName, p.Name)
+	}
+
+	p.Environments[i].Name = newName
+	for j := range p.Environments {
+		for k, dep := range p.Environments[j].DependsOn {
+			if dep == name {
+				p.Environments[j].DependsOn[k] = newName
+			}
+		}
+	}
+	return nil
+}
+
+// MoveEnvironment moves an environment of the project to another project,
+// under newName. The environment cannot take its dependencies along, so it
+// can neither depend on nor be a dependency of other environments.
+func (p *ProjectConfig) MoveEnvironment(name string, to *ProjectConfig, newName string) error {
+	if p.ID == to.ID {
+		return fmt.Errorf("environment '%s' already belongs to project '%s'", name, p.Name)
+	}
+	i := slices.IndexFunc(p.Environments, func(e EnvironmentConfig) bool { return e.Name == name })
+	if i < 0 {
+		return fmt.Errorf("environment '%s' does not exist in project '%s'", name, p.Name)
+	}
+	env := p.Environments[i]
+	if len(env.DependsOn) > 0 {
+		return fmt.Errorf("environment '%s' depends on %s, remove them from its depends_on first", name, strings.Join(env.DependsOn, ", "))
+	}
+	if dependents := p.Dependents(name); len(dependents) > 0 {
+		return fmt.Errorf("environment '%s' is a dependency of %s, remove it from their depends_on first", name, strings.Join(dependents, ", "))
+	}
+	if strings.TrimSpace(newName) == "" {
+		return fmt.Errorf("environment name cannot be empty")
+	}
+	if slices.ContainsFunc(to.Environments, func(e EnvironmentConfig) bool { return e.Name == newName }) {
+		return fmt.Errorf("environment with name '%s' already exists in project '%s'", newName, to.Name)
+	}
+
+	env.Name = newName
+	p.Environments = slices.Delete(p.Environments, i, i+1)
+	to.Environments = append(to.Environments, env)
+	to.RefreshTools()
+	return nil
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Setting is a scalar value of the global configuration which can be read and
+// changed with "devkit config"
+type Setting struct {
+	// Key is the name of the setting in the configuration file
+	Key     string `json:"key"`
+	Type    string `json:"type"`
+	Default string `json:"default"`
+
+	field string
+}
+
+// Settings returns the settings of the configuration, in the order of the file
+func Settings() []Setting {
+	typ := reflect.TypeOf(GlobalConfig{})
+
+	var settings []Setting
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		key := settingKey(field)
+		switch {
+		case key == "" || key == "version" || key == "checked_tools" || key == "last_project_id":
+			// runtime values, the version managed by the migrations and the
+			// state written by devkit itself
+			continue
+		case field.Type.Kind() != reflect.Bool && field.Type.Kind() != reflect.String && field.Type.Kind() != reflect.Int:
+			// projects and tools have their own commands
+			continue
+		}
+		def := field.Tag.Get("default")
+		if def == "" {
+			def = fmt.Sprint(reflect.Zero(field.Type).Interface())
+		}
+		settings = append(settings, Setting{
+			Key:     key,
+			Type:    field.Type.Kind().String(),
+			Default: def,
+			field:   field.Name,
+		})
+	}
+	return settings
+}
+
+// settingKey returns the key of a field in the configuration file, empty when
+// it is not saved
+func settingKey(field reflect.StructField) string {
+	key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	switch key {
+	case "-":
+		return ""
+	case "":
+		return strings.ToLower(field.Name)
+	}
+	return key
+}
+
+// FindSetting returns the setting with the given key, which is also matched
+// against the names of the fields, such as LOG_FORMAT for log_format
+func FindSetting(key string) (Setting, error) {
+	var keys []string
+	for _, setting := range Settings() {
+		if strings.EqualFold(setting.Key, key) || strings.EqualFold(setting.field, key) {
+			return setting, nil
+		}
+		keys = append(keys, setting.Key)
+	}
+	return Setting{}, fmt.Errorf("unknown setting '%s', use one of %s", key, strings.Join(keys, ", "))
+}
+
+func (cfg *GlobalConfig) settingValue(key string) (Setting, reflect.Value, error) {
+	setting, err := FindSetting(key)
+	if err != nil {
+		return setting, reflect.Value{}, err
+	}
+	return setting, reflect.ValueOf(cfg).Elem().FieldByName(setting.field), nil
+}
+
+// Get returns the value of a setting
+func (cfg *GlobalConfig) Get(key string) (string, error) {
+	_, field, err := cfg.settingValue(key)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprint(field.Interface()), nil
+}
+
+// Set parses value according to the type of the setting and sets it
+func (cfg *GlobalConfig) Set(key, value string) error {
+	setting, field, err := cfg.settingValue(key)
+	if err != nil {
+		return err
+	}
+	if err := setField(field, value); err != nil {
+		return fmt.Errorf("invalid value '%s' for %s: %v", value, setting.Key, err)
+	}
+	cfg.markSet(setting.Key, true)
+	return validateAndSetDefaults(cfg)
+}
+
+// Unset removes a setting from the configuration, it goes back to its default
+// value unless another layer sets it
+func (cfg *GlobalConfig) Unset(key string) error {
+	setting, field, err := cfg.settingValue(key)
+	if err != nil {
+		return err
+	}
+	if err := setField(field, setting.Default); err != nil {
+		return err
+	}
+	cfg.markSet(setting.Key, false)
+	return validateAndSetDefaults(cfg)
+}
+
+// setField parses value into a bool, int or string field
+func setField(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("expected true or false")
+		}
+		field.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("expected a number")
+		}
+		field.SetInt(int64(n))
+	default:
+		return fmt.Errorf("%s values cannot be set", field.Kind())
+	}
+	return nil
+}
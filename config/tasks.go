@@ -0,0 +1,152 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Sources of a task
+const (
+	TaskSourceEnvironment = "environment"
+	TaskSourceProject     = "project"
+	TaskSourceBuild       = "build"
+	TaskSourceTest        = "test"
+)
+
+// TaskConfig is a named step of an environment, run through the shell in the
+// path of the environment. In YAML a task can be written as its command only.
+type TaskConfig struct {
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Command     string `json:"command"`
+
+	// DependsOn lists the tasks run before this one, such as build for test
+	DependsOn []string `json:"depends_on,omitempty" yaml:"depends_on,omitempty"`
+}
+
+func (t *TaskConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		t.Command = node.Value
+		return nil
+	}
+	type plain TaskConfig
+	return node.Decode((*plain)(t))
+}
+
+// Task is a task available in an environment
+type Task struct {
+	Name string `json:"name"`
+	TaskConfig
+
+	// Source tells where the task is declared, see the TaskSource constants
+	Source string `json:"source"`
+}
+
+// EnvironmentTasks returns the tasks of env by name: its own, the ones of the
+// project it does not override, and its build and test commands when no
+// task is named after them, the test command depending on the build one
+func (p *ProjectConfig) EnvironmentTasks(env *EnvironmentConfig) map[string]Task {
+	tasks := map[string]Task{}
+	if env.Build != "" {
+		tasks["build"] = Task{Name: "build", TaskConfig: TaskConfig{Description: "build the environment", Command: env.Build}, Source: TaskSourceBuild}
+	}
+	if env.Test != "" {
+		test := Task{Name: "test", TaskConfig: TaskConfig{Description: "test the environment", Command: env.Test}, Source: TaskSourceTest}
+		if env.Build != "" {
+			test.DependsOn = []string{"build"}
+		}
+		tasks["test"] = test
+	}
+	for name, task := range p.Tasks {
+		tasks[name] = Task{Name: name, TaskConfig: task, Source: TaskSourceProject}
+	}
+	for name, task := range env.Tasks {
+		tasks[name] = Task{Name: name, TaskConfig: task, Source: TaskSourceEnvironment}
+	}
+	return tasks
+}
+
+// SortedTasks returns the tasks ordered by name
+func SortedTasks(tasks map[string]Task) []Task {
+	sorted := make([]Task, 0, len(tasks))
+	for _, task := range tasks {
+		sorted = append(sorted, task)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+// TaskPlan returns the tasks to run for the task name, its dependencies first
+// and each of them once. It fails on unknown tasks, tasks without a command and
+// dependency cycles.
+func TaskPlan(tasks map[string]Task, name string) ([]Task, error) {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[string]int{}
+	var plan []Task
+	var path []string
+
+	var visit func(name, dependent string) error
+	visit = func(name, dependent string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			start := 0
+			for i, n := range path {
+				if n == name {
+					start = i
+				}
+			}
+			cycle := append(append([]string{}, path[start:]...), name)
+			return fmt.Errorf("task dependency cycle: %s", strings.Join(cycle, " -> "))
+		}
+		task, ok := tasks[name]
+		if !ok {
+			if dependent != "" {
+				return fmt.Errorf("task '%s' depends on unknown task '%s'", dependent, name)
+			}
+			return fmt.Errorf("unknown task '%s'", name)
+		}
+
+		if strings.TrimSpace(task.Command) == "" {
+			return fmt.Errorf("task '%s' has no command", name)
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range task.DependsOn {
+			if err := visit(dep, name); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+		plan = append(plan, task)
+		return nil
+	}
+
+	if err := visit(name, ""); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// validateTasks checks that the tasks of every environment have a command and
+// known dependencies without cycles
+func (p *ProjectConfig) validateTasks() error {
+	for _, env := range p.Environments {
+		tasks := p.EnvironmentTasks(&env)
+		for _, task := range SortedTasks(tasks) {
+			if _, err := TaskPlan(tasks, task.Name); err != nil {
+				return fmt.Errorf("environment '%s': %v", env.Name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ToolRequirement describes a tool checked by "devkit init check"
+type ToolRequirement struct {
+	Name string `json:"name"`
+
+	// Binary is the executable looked up on PATH, the name is used when it is
+	// empty
+	Binary string `json:"binary"`
+
+	// VersionArgs are the arguments printing the version of the tool
+	VersionArgs []string `json:"version_args" yaml:"version_args"`
+
+	// MinVersion is the oldest accepted version, any version is accepted when
+	// it is empty
+	MinVersion string `json:"min_version" yaml:"min_version"`
+
+	// Required tools have to pass the check before devkit can be used, the
+	// others only produce a warning
+	Required bool `json:"required"`
+}
+
+// Executable returns the executable of the tool
+func (t ToolRequirement) Executable() string {
+	if t.Binary != "" {
+		return t.Binary
+	}
+	return t.Name
+}
+
+// defaultTools are checked when the configuration does not list any tool, the
+// tools of the languages used by a project are required by the project itself
+var defaultTools = []ToolRequirement{
+	{Name: "git", VersionArgs: []string{"--version"}, MinVersion: "2.20", Required: true},
+	{Name: "docker", VersionArgs: []string{"--version"}, MinVersion: "20.10"},
+	{Name: "kind", VersionArgs: []string{"version"}, MinVersion: "0.20"},
+}
+
+// CheckedTools returns the tools checked by "devkit init check", the configured
+// ones or the defaults
+func (cfg *GlobalConfig) CheckedTools() []ToolRequirement {
+	if len(cfg.TOOLS) > 0 {
+		return cfg.TOOLS
+	}
+	return append([]ToolRequirement(nil), defaultTools...)
+}
+
+// toolCatalog describes the tools projects can require, by name
+var toolCatalog = map[string]ToolRequirement{
+	"go":      {Name: "go", VersionArgs: []string{"version"}, MinVersion: "1.21"},
+	"node":    {Name: "node", VersionArgs: []string{"--version"}, MinVersion: "18"},
+	"npm":     {Name: "npm", VersionArgs: []string{"--version"}},
+	"yarn":    {Name: "yarn", VersionArgs: []string{"--version"}},
+	"pnpm":    {Name: "pnpm", VersionArgs: []string{"--version"}},
+	"python3": {Name: "python3", VersionArgs: []string{"--version"}, MinVersion: "3.8"},
+	"poetry":  {Name: "poetry", VersionArgs: []string{"--version"}},
+	"cargo":   {Name: "cargo", VersionArgs: []string{"--version"}},
+	"java":    {Name: "java", VersionArgs: []string{"-version"}},
+	"mvn":     {Name: "mvn", VersionArgs: []string{"--version"}},
+	"gradle":  {Name: "gradle", VersionArgs: []string{"--version"}},
+	"make":    {Name: "make", VersionArgs: []string{"--version"}},
+	"docker":  {Name: "docker", VersionArgs: []string{"--version"}, MinVersion: "20.10"},
+}
+
+// languageTools lists the tools needed by the languages of environments
+var languageTools = map[string][]string{
+	"go":         {"go"},
+	"golang":     {"go"},
+	"node":       {"node"},
+	"nodejs":     {"node"},
+	"js":         {"node"},
+	"javascript": {"node"},
+	"ts":         {"node"},
+	"typescript": {"node"},
+	"python":     {"python3"},
+	"py":         {"python3"},
+	"rust":       {"cargo"},
+	"java":       {"java"},
+	"kotlin":     {"java"},
+	"make":       {"make"},
+	"docker":     {"docker"},
+}
+
+// manifestTools lists the tools needed when a file is found in the path of an
+// environment
+var manifestTools = []struct {
+	file  string
+	tools []string
+}{
+	{"go.mod", []string{"go"}},
+	{"package.json", []string{"node"}},
+	{"package-lock.json", []string{"npm"}},
+	{"yarn.lock", []string{"yarn"}},
+	{"pnpm-lock.yaml", []string{"pnpm"}},
+	{"pyproject.toml", []string{"python3"}},
+	{"requirements.txt", []string{"python3"}},
+	{"setup.py", []string{"python3"}},
+	{"poetry.lock", []string{"poetry"}},
+	{"Cargo.toml", []string{"cargo"}},
+	{"pom.xml", []string{"java", "mvn"}},
+	{"build.gradle", []string{"java", "gradle"}},
+	{"build.gradle.kts", []string{"java", "gradle"}},
+	{"Makefile", []string{"make"}},
+	{"Dockerfile", []string{"docker"}},
+}
+
+// RequiredTools returns the names of the tools needed by an environment, from
+// its language and the manifests found in its path
+func (env EnvironmentConfig) RequiredTools() []string {
+	var names []string
+	add := func(tools ...string) {
+		for _, tool := range tools {
+			if !slices.Contains(names, tool) {
+				names = append(names, tool)
+			}
+		}
+	}
+
+	add(languageTools[strings.ToLower(strings.TrimSpace(env.Language))]...)
+	if env.Path != "" {
+		for _, m := range manifestTools {
+			if _, err := os.Stat(filepath.Join(env.Path, m.file)); err == nil {
+				add(m.tools...)
+			}
+		}
+		// projects shipping the Maven or Gradle wrapper do not need the tool
+		// itself
+		for wrapper, tool := range map[string]string{"mvnw": "mvn", "gradlew": "gradle"} {
+			if _, err := os.Stat(filepath.Join(env.Path, wrapper)); err == nil {
+				names = slices.DeleteFunc(names, func(name string) bool { return name == tool })
+			}
+		}
+	}
+	// node projects without the lock file of another package manager are
+	// installed with npm
+	if slices.Contains(names, "node") && !slices.Contains(names, "yarn") && !slices.Contains(names, "pnpm") {
+		add("npm")
+	}
+	return names
+}
+
+// RefreshTools adds the tools needed by the environments of the project to its
+// Tools, keeping the requirements already listed there. Adding a tool clears
+// ToolsChecked, and true is returned.
+func (p *ProjectConfig) RefreshTools() bool {
+	added := false
+	for _, env := range p.Environments {
+		for _, name := range env.RequiredTools() {
+			if slices.ContainsFunc(p.Tools, func(t ToolRequirement) bool { return t.Name == name }) {
+				continue
+			}
+			tool := toolCatalog[name]
+			tool.Required = true
+			p.Tools = append(p.Tools, tool)
+			added = true
+		}
+	}
+	if added {
+		p.ToolsChecked = false
+	}
+	return added
+}
+
+// ToolNames returns the names of the tools of the project
+func (p *ProjectConfig) ToolNames() []string {
+	names := make([]string, len(p.Tools))
+	for i, tool := range p.Tools {
+		names[i] = tool.Name
+	}
+	return names
+}
@@ -28,38 +28,37 @@ func (s *Server) UpdateProject(ctx context.Context, req *pb.ProjectRequest) (*pb
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	for i, p := range s.config.Projects {
-		if p.ID == req.ProjectId {
-			// Update project
-			s.config.Projects[i] = config.ProjectConfig{
-				ID:             req.Project.Id,
-				Name:           req.Project.Name,
-				Description:    req.Project.Description,
-				IsMicroservice: req.Project.IsMicroservice,
-				IsValid:        true, // Set default value
-				Environments:   make([]config.EnvironmentConfig, len(req.Project.Environments)),
-			}
-
-			for j, env := range req.Project.Environments {
-				s.config.Projects[i].Environments[j] = config.EnvironmentConfig{
-					Name:        env.Name,
-					Description: env.Description,
-					Language:    env.Language,
-					Path:        env.Path,
+	var updated config.ProjectConfig
+	err := s.updateConfig(func(cfg *config.GlobalConfig) error {
+		for i, p := range cfg.Projects {
+			if p.ID == req.ProjectId {
+				// Update project
+				cfg.Projects[i] = config.ProjectConfig{
+					ID:             req.Project.Id,
+					Name:           req.Project.Name,
+					Description:    req.Project.Description,
+					IsMicroservice: req.Project.IsMicroservice,
+					IsValid:        true, // Set default value
+					Environments:   make([]config.EnvironmentConfig, len(req.Project.Environments)),
+					Env:            req.Project.Env,
 				}
-			}
 
-			if err := saveConfig(s.config); err != nil {
-				return nil, status.Errorf(codes.Internal, "failed to save config: %v", err)
+				for j, env := range req.Project.Environments {
+					cfg.Projects[i].Environments[j] = convertFromProtoEnvironment(env)
+				}
+				updated = cfg.Projects[i]
+				return nil
 			}
-
-			return &pb.ProjectResponse{
-				Project: convertToProtoProject(s.config.Projects[i]),
-			}, nil
 		}
+		return status.Errorf(codes.NotFound, "project not found")
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, status.Errorf(codes.NotFound, "project not found")
+	return &pb.ProjectResponse{
+		Project: convertToProtoProject(updated),
+	}, nil
 }
 
 func (s *Server) ListProjects(ctx context.Context, _ *pb.Empty) (*pb.ListProjectsResponse, error) {
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
@@ -32,36 +31,34 @@ func loadConfig() (*config.GlobalConfig, error) {
 		return nil, fmt.Errorf("failed to read config file: %v", err)
 	}
 
-	var config config.GlobalConfig
-	if err := json.Unmarshal(file, &config); err != nil {
+	var cfg config.GlobalConfig
+	if _, err := config.DecodeConfig(file, &cfg); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %v", err)
 	}
 
-	return &config, nil
+	return &cfg, nil
 }
 
-func saveConfig(config *config.GlobalConfig) error {
-	data, err := json.MarshalIndent(config, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal config: %v", err)
+// updateConfig changes the configuration with fn in a read-modify-write cycle
+// of the file, so that the changes saved by the CLI since the server started
+// are kept, and makes the result the configuration of the server. The errors
+// of fn are returned as they are, they have to be gRPC status errors.
+func (s *Server) updateConfig(fn func(cfg *config.GlobalConfig) error) error {
+	err := config.Update(fn)
+	if _, ok := status.FromError(err); !ok {
+		return status.Errorf(codes.Internal, "failed to save config: %v", err)
 	}
-
-	if err := os.WriteFile("config.json", data, 0644); err != nil {
-		return fmt.Errorf("failed to write config file: %v", err)
+	if err != nil {
+		return err
 	}
-
+	s.config = config.GetConfig()
 	return nil
 }
 
 func convertToProtoProject(p config.ProjectConfig) *pb.ProjectConfig {
 	environments := make([]*pb.EnvironmentConfig, len(p.Environments))
 	for i, env := range p.Environments {
-		environments[i] = &pb.EnvironmentConfig{
-			Name:        env.Name,
-			Description: env.Description,
-			Language:    env.Language,
-			Path:        env.Path,
-		}
+		environments[i] = convertToProtoEnvironment(env)
 	}
 
 	return &pb.ProjectConfig{
@@ -70,6 +67,67 @@ func convertToProtoProject(p config.ProjectConfig) *pb.ProjectConfig {
 		Description:    p.Description,
 		IsMicroservice: p.IsMicroservice,
 		Environments:   environments,
+		Env:            p.Env,
+	}
+}
+
+func convertToProtoEnvironment(env config.EnvironmentConfig) *pb.EnvironmentConfig {
+	return &pb.EnvironmentConfig{
+		Name:        env.Name,
+		Description: env.Description,
+		Language:    env.Language,
+		Path:        env.Path,
+		Command:     env.Command,
+		Args:        env.Args,
+		Shell:       env.Shell,
+		Restart:     env.Restart,
+		MaxRestarts: int32(env.MaxRestarts),
+		Watch: &pb.WatchConfig{
+			Include: env.Watch.Include,
+			Exclude: env.Watch.Exclude,
+		},
+		DependsOn: env.DependsOn,
+		Ready: &pb.ReadinessProbe{
+			Tcp:      env.Ready.TCP,
+			Http:     env.Ready.HTTP,
+			Command:  env.Ready.Command,
+			Timeout:  env.Ready.Timeout,
+			Interval: env.Ready.Interval,
+		},
+		Env:      env.Env,
+		EnvFiles: env.EnvFiles,
+		Build:    env.Build,
+		Test:     env.Test,
+	}
+}
+
+func convertFromProtoEnvironment(env *pb.EnvironmentConfig) config.EnvironmentConfig {
+	return config.EnvironmentConfig{
+		Name:        env.GetName(),
+		Description: env.GetDescription(),
+		Language:    env.GetLanguage(),
+		Path:        env.GetPath(),
+		Command:     env.GetCommand(),
+		Args:        env.GetArgs(),
+		Shell:       env.GetShell(),
+		Restart:     env.GetRestart(),
+		MaxRestarts: int(env.GetMaxRestarts()),
+		Watch: config.WatchConfig{
+			Include: env.GetWatch().GetInclude(),
+			Exclude: env.GetWatch().GetExclude(),
+		},
+		DependsOn: env.GetDependsOn(),
+		Ready: config.ReadinessProbe{
+			TCP:      env.GetReady().GetTcp(),
+			HTTP:     env.GetReady().GetHttp(),
+			Command:  env.GetReady().GetCommand(),
+			Timeout:  env.GetReady().GetTimeout(),
+			Interval: env.GetReady().GetInterval(),
+		},
+		Env:      env.GetEnv(),
+		EnvFiles: env.GetEnvFiles(),
+		Build:    env.GetBuild(),
+		Test:     env.GetTest(),
 	}
 }
 
@@ -98,16 +156,18 @@ func (s *Server) UpdateGlobalConfig(ctx context.Context, req *pb.GlobalConfigReq
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.config.DEBUG = req.Config.Debug
-	s.config.PPROF_ENABLED = req.Config.PprofEnabled
-	s.config.PPROF_ADD_AND_PORT = req.Config.PprofAddAndPort
-	s.config.LOG_FORMAT = req.Config.LogFormat
-	s.config.KUBECONFIG = req.Config.Kubeconfig
-	s.config.CHECKED_TOOLS = req.Config.CheckedTools
-	s.config.CURRENT_CMD = req.Config.CurrentCmd
-
-	if err := saveConfig(s.config); err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to save config: %v", err)
+	err := s.updateConfig(func(cfg *config.GlobalConfig) error {
+		cfg.DEBUG = req.Config.Debug
+		cfg.PPROF_ENABLED = req.Config.PprofEnabled
+		cfg.PPROF_ADD_AND_PORT = req.Config.PprofAddAndPort
+		cfg.LOG_FORMAT = req.Config.LogFormat
+		cfg.KUBECONFIG = req.Config.Kubeconfig
+		cfg.CHECKED_TOOLS = req.Config.CheckedTools
+		cfg.CURRENT_CMD = req.Config.CurrentCmd
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return req.Config, nil
@@ -119,33 +179,31 @@ func (s *Server) CreateEnvironment(ctx context.Context, req *pb.CreateEnvironmen
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Find the project by ID
-	for _, project := range s.config.Projects {
-		if project.ID == req.ProjectId {
+	err := s.updateConfig(func(cfg *config.GlobalConfig) error {
+		// Find the project by ID
+		for i := range cfg.Projects {
+			project := &cfg.Projects[i]
+			if project.ID != req.ProjectId {
+				continue
+			}
 			// Check for duplicate environment names
 			for _, env := range project.Environments {
 				if env.Name == req.Environment.Name {
-					return nil, status.Errorf(codes.AlreadyExists, "environment with this name already exists")
+					return status.Errorf(codes.AlreadyExists, "environment with this name already exists")
 				}
 			}
 
 			// Append the new environment
-			project.Environments = append(project.Environments, config.EnvironmentConfig{
-				Name:        req.Environment.Name,
-				Description: req.Environment.Description,
-				Path:        req.Environment.Path,
-			})
-
-			// Save the updated configuration
-			if err := saveConfig(s.config); err != nil {
-				return nil, status.Errorf(codes.Internal, "failed to save config: %v", err)
-			}
-
-			return &pb.Empty{}, nil
+			project.Environments = append(project.Environments, convertFromProtoEnvironment(req.Environment))
+			return nil
 		}
+		return status.Errorf(codes.NotFound, "project not found")
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, status.Errorf(codes.NotFound, "project not found")
+	return &pb.Empty{}, nil
 }
 
 func main() {